@@ -0,0 +1,80 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func CheckCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("check", runCheck,
+		domovoi.WithArgs(cobra.MaximumNArgs(1)),
+	))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runCheck(cmd *cobra.Command, args []string) {
+	const op = "hypnos.check"
+
+	var configFiles []string
+	entries, err := domovoi.ReadDir(configDirs.config, rootFlags.verbose)
+	if len(args) == 0 {
+		horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".toml") {
+			configFiles = append(configFiles, filepath.Join(configDirs.config, e.Name()))
+		}
+	}
+
+	// a single file is checked on its own, but may extend workflows from the config dir
+	paths, context := configFiles, []string(nil)
+	if len(args) == 1 {
+		paths, context = args, configFiles
+	}
+
+	issues := checkConfigFiles(paths, context)
+	for _, issue := range issues {
+		fmt.Println(chalk.Red.Color(issue.String()))
+	}
+
+	if len(issues) > 0 {
+		horus.CheckErr(
+			errors.New(""),
+			horus.WithOp(op),
+			horus.WithMessage(fmt.Sprintf("%d problem(s) found in %d file(s)", len(issues), len(paths))),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Message) }),
+		)
+	}
+
+	fmt.Printf("%s %d file(s) checked, no problems found\n", chalk.Green.Color("OK:"), len(paths))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
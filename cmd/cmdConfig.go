@@ -0,0 +1,129 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func ConfigCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("config", nil))
+	cmd.AddCommand(ConfigGetCmd(), ConfigSetCmd(), ConfigListCmd())
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func ConfigGetCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("config-get", runConfigGet,
+		domovoi.WithArgs(cobra.ExactArgs(1)),
+		domovoi.WithValidArgsFunction(completeSettingKeys),
+	))
+}
+
+func ConfigSetCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("config-set", runConfigSet,
+		domovoi.WithArgs(cobra.ExactArgs(2)),
+		domovoi.WithValidArgsFunction(completeSettingKeys),
+	))
+}
+
+func ConfigListCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("config-list", runConfigList))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	const op = "hypnos.config.get"
+
+	if _, ok := settingKeys[args[0]]; !ok {
+		horus.CheckErr(
+			fmt.Errorf("unknown setting %q (valid: %s)", args[0], strings.Join(sortedKeys(settingKeys), ", ")),
+			horus.WithOp(op),
+			horus.WithExitCode(2),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+		)
+	}
+	fmt.Println(effectiveSetting(args[0]))
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	const op = "hypnos.config.set"
+
+	horus.CheckErr(
+		writeSetting(args[0], args[1]),
+		horus.WithOp(op),
+		horus.WithCategory("config_error"),
+		horus.WithExitCode(2),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+	fmt.Printf("%s %s = %q in %s\n", chalk.Green.Color("OK:"), args[0], args[1], settingsPath())
+}
+
+func runConfigList(cmd *cobra.Command, args []string) {
+	fmt.Printf("# %s\n", settingsPath())
+	fmt.Printf("%-15s %-20s %-12s %s\n", "KEY", "VALUE", "SOURCE", "DESCRIPTION")
+	for _, key := range sortedKeys(settingKeys) {
+		source := "built-in"
+		switch {
+		case key == "store" && os.Getenv("HYPNOS_STORE") != "":
+			source = "HYPNOS_STORE"
+		case settings.InConfig(key):
+			source = settingsFileName
+		}
+		fmt.Printf("%-15s %-20s %-12s %s\n", key, fmt.Sprintf("%q", effectiveSetting(key)), source, settingKeys[key].doc)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// effectiveSetting is the value hypnos acts on, including environment overrides
+func effectiveSetting(key string) string {
+	if key == "store" {
+		if env := os.Getenv("HYPNOS_STORE"); env != "" {
+			return env
+		}
+	}
+	return settings.GetString(key)
+}
+
+func completeSettingKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, key := range sortedKeys(settingKeys) {
+		if strings.HasPrefix(key, toComplete) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
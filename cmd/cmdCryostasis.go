@@ -22,7 +22,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"syscall"
 
 	"github.com/DanielRivasMD/domovoi"
@@ -34,8 +33,9 @@ import (
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 var cryostasisFlags struct {
-	all   bool
-	group string
+	all    bool
+	group  string
+	dryRun bool
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -48,6 +48,7 @@ func CryostasisCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&cryostasisFlags.all, "all", false, "stasis all probes")
 	cmd.Flags().StringVar(&cryostasisFlags.group, "group", "", "stasis all probes in a specific group")
+	cmd.Flags().BoolVar(&cryostasisFlags.dryRun, "dry-run", false, "print the PIDs that would be signalled and the files removed")
 
 	horus.CheckErr(
 		cmd.RegisterFlagCompletionFunc("group", completeProbeGroups),
@@ -89,28 +90,39 @@ func cryostasisProbe(name string) {
 	const op = "hypnos.stasis"
 
 	meta := loadProbeMeta(name)
+	if cryostasisFlags.dryRun {
+		previewStasis(meta)
+		return
+	}
 
-	if err := syscall.Kill(meta.PID, syscall.SIGTERM); err != nil {
-		if err == syscall.ESRCH {
-			fmt.Printf("warning: process %d for %q not running\n", meta.PID, name)
-		} else {
-			fmt.Fprintf(os.Stderr, "error: cannot kill PID %d for %q (%v)\n", meta.PID, name, err)
-			return
-		}
-	} else {
+	viaSocket, err := stopWorker(meta)
+	switch {
+	case errors.Is(err, syscall.ESRCH):
+		fmt.Printf("warning: process %d for %q not running\n", meta.PID, name)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "error: cannot stop PID %d for %q (%v)\n", meta.PID, name, err)
+		return
+	case viaSocket:
+		fmt.Printf("%s worker PID %d acknowledged stop for %q\n", chalk.Green.Color("OK:"), meta.PID, name)
+	default:
 		fmt.Printf("%s sent SIGTERM to PID %d for %q\n", chalk.Green.Color("OK:"), meta.PID, name)
 	}
 
+	// a worker still running may write its log again, recreating it after the removal
+	exited := err != nil || waitStopped(meta, exitTimeout)
+
 	horus.CheckErr(
-		func() error {
-			_, err := domovoi.RemoveFile(filepath.Join(configDirs.probe, name+".json"), rootFlags.verbose)(filepath.Join(configDirs.probe, name+".json"))
-			return err
-		}(),
+		probes().Remove(name),
 		horus.WithOp(op),
 		horus.WithCategory("io_error"),
-		horus.WithMessage("removing metadata file"),
+		horus.WithMessage("removing probe metadata"),
 	)
 
+	if !exited {
+		fmt.Printf("warning: PID %d for %q still running after %s; log kept at %s\n", meta.PID, name, exitTimeout, meta.LogPath)
+		return
+	}
+
 	horus.CheckErr(
 		func() error {
 			_, err := domovoi.RemoveFile(meta.LogPath, rootFlags.verbose)(meta.LogPath)
@@ -127,9 +139,9 @@ func cryostasisProbe(name string) {
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func cryostasisGroupProbes(group string) {
-	for _, metaFile := range listProbeMetaFiles() {
-		if matchProbeGroup(metaFile, group) {
-			cryostasisProbe(stripProbeName(metaFile))
+	for _, name := range listProbeNames() {
+		if matchProbeGroup(name, group) {
+			cryostasisProbe(name)
 		}
 	}
 }
@@ -137,8 +149,8 @@ func cryostasisGroupProbes(group string) {
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func cryostasisAllProbes() {
-	for _, metaFile := range listProbeMetaFiles() {
-		cryostasisProbe(stripProbeName(metaFile))
+	for _, name := range listProbeNames() {
+		cryostasisProbe(name)
 	}
 }
 
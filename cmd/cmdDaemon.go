@@ -0,0 +1,146 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var daemonFlags struct {
+	foreground bool
+	timeout    time.Duration
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func DaemonCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("daemon", nil))
+	cmd.AddCommand(DaemonStartCmd(), DaemonStopCmd(), DaemonStatusCmd())
+	return cmd
+}
+
+func DaemonStartCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("daemon-start", runDaemonStart))
+	cmd.Flags().BoolVar(&daemonFlags.foreground, "foreground", false, "run in this process instead of detaching")
+	return cmd
+}
+
+func DaemonStopCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("daemon-stop", runDaemonStop))
+	cmd.Flags().DurationVar(&daemonFlags.timeout, "timeout", time.Minute, "how long to wait for runs in progress before giving up")
+	return cmd
+}
+
+func DaemonStatusCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("daemon-status", runDaemonStatus))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runDaemonStart(cmd *cobra.Command, args []string) {
+	const op = "hypnos.daemon.start"
+
+	if reply, err := callDaemon(controlRequest{Op: opStatus}); err == nil {
+		daemonFail(op, fmt.Errorf("daemon already running (PID %d)", reply.PID))
+	}
+
+	if daemonFlags.foreground {
+		daemonFail(op, newDaemon().serve())
+		return
+	}
+
+	// the detached daemon is this command again, in the foreground of its own process
+	exe, err := os.Executable()
+	daemonFail(op, err)
+	daemonFail(op, os.MkdirAll(configDirs.log, 0o755))
+	pid, err := startWorker(exe, []string{"daemon", "start", "--foreground"}, nil, daemonLogPath())
+	daemonFail(op, err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := callDaemon(controlRequest{Op: opStatus}); err == nil {
+			break
+		}
+		if time.Now().After(deadline) || processState(pid) == "" {
+			daemonFail(op, fmt.Errorf("daemon PID %d did not come up; see %s", pid, daemonLogPath()))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	fmt.Printf("%s daemon started with PID %d (log %s)\n", chalk.Green.Color("OK:"), pid, daemonLogPath())
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) {
+	const op = "hypnos.daemon.stop"
+
+	reply, err := callDaemon(controlRequest{Op: opShutdown})
+	if errors.Is(err, errNoSocket) {
+		err = errors.New("no daemon running")
+	}
+	daemonFail(op, err)
+
+	// shutdown lets runs in progress finish, so wait for the process rather than the reply
+	deadline := time.Now().Add(daemonFlags.timeout)
+	for processState(reply.PID) != "" {
+		if time.Now().After(deadline) {
+			daemonFail(op, fmt.Errorf("daemon PID %d still shutting down after %s; see %s, or kill it to abandon its runs",
+				reply.PID, daemonFlags.timeout, daemonLogPath()))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	fmt.Printf("%s daemon PID %d stopped\n", chalk.Green.Color("OK:"), reply.PID)
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) {
+	const op = "hypnos.daemon.status"
+
+	reply, err := callDaemon(controlRequest{Op: opStatus})
+	if errors.Is(err, errNoSocket) {
+		fmt.Println("no daemon running; probes run as separate worker processes")
+		return
+	}
+	daemonFail(op, err)
+
+	fmt.Printf("daemon PID %d, up %s, %d probe(s)", reply.PID, time.Since(reply.Since).Truncate(time.Second), len(reply.Probes))
+	if len(reply.Probes) > 0 {
+		fmt.Printf(": %s", strings.Join(reply.Probes, ", "))
+	}
+	fmt.Println()
+}
+
+func daemonFail(op string, err error) {
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("daemon_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
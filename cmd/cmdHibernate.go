@@ -24,13 +24,12 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/DanielRivasMD/Hypnos/hypnos"
 	"github.com/DanielRivasMD/domovoi"
 	"github.com/DanielRivasMD/horus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"github.com/ttacon/chalk"
 )
 
@@ -47,6 +46,35 @@ type configPaths struct {
 	iterations int
 	notify     bool
 	carbonite  bool
+	env        []string
+	envFile    string
+	workdir    string
+	shell      string
+	timeout    time.Duration
+	overlap    string
+
+	retries         int
+	retryBackoff    time.Duration
+	retryBackoffMax time.Duration
+	successCodes    []int
+
+	notifyOn      string
+	notifyTitle   string
+	notifyMessage string
+	notifyTimeout bool
+
+	onSuccess string
+	onFailure string
+	lineage   []string
+
+	vars      []string
+	singleton bool
+	step      string
+
+	// the launching shell's environment and directory, for probes run by the daemon; nil and
+	// empty mean the worker's own, which a forked worker inherits from that shell anyway
+	environ []string
+	dir     string
 }
 
 var (
@@ -54,6 +82,13 @@ var (
 	worker   configPaths
 )
 
+// launchFlags decide what happens when the probe name is already taken
+var launchFlags struct {
+	replace bool
+	unique  bool
+	dryRun  bool
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func HibernateLauncherCmd() *cobra.Command {
@@ -63,7 +98,7 @@ func HibernateLauncherCmd() *cobra.Command {
 		domovoi.WithPreRun(preRunHibernate),
 	))
 
-	cmd.Flags().StringVarP(&launcher.probe, "probe", "", "", "instance name (manual or default: <config>-<ts>)")
+	cmd.Flags().StringVarP(&launcher.probe, "probe", "", "", "instance name (default: workflow name)")
 	cmd.Flags().StringVarP(&launcher.group, "group", "g", "", "group label for this probe")
 	cmd.Flags().StringVarP(&launcher.log, "log", "", "", "log file basename (no .log)")
 	cmd.Flags().StringVarP(&launcher.script, "script", "", "", "shell command to execute")
@@ -72,6 +107,35 @@ func HibernateLauncherCmd() *cobra.Command {
 	cmd.Flags().IntVarP(&launcher.iterations, "iterations", "", 0, "run this many times (0=unlimited if --recurrent)")
 	cmd.Flags().BoolVar(&launcher.notify, "notify-only", false, "only send notification, skip script execution")
 	cmd.Flags().BoolVar(&launcher.carbonite, "carbonite", false, "run script as a perpetual background process (daemon)")
+	cmd.Flags().StringArrayVar(&launcher.env, "env", nil, "extra environment variable KEY=VALUE (repeatable)")
+	cmd.Flags().StringVar(&launcher.envFile, "env-file", "", "file of KEY=VALUE lines to add to the environment")
+	cmd.Flags().StringVar(&launcher.workdir, "workdir", "", "working directory for the script")
+	cmd.Flags().StringVar(&launcher.shell, "shell", "", "shell to run the script with: sh, bash, zsh or exec (argv, no shell)")
+	cmd.Flags().DurationVar(&launcher.timeout, "timeout", 0, "kill the script's process group after this long (0=no timeout)")
+	cmd.Flags().StringVar(&launcher.overlap, "overlap", "", "when a timer fires mid-run: skip, queue (default) or parallel")
+	cmd.Flags().IntVar(&launcher.retries, "retries", 0, "retry a failed script this many times")
+	cmd.Flags().DurationVar(&launcher.retryBackoff, "retry-backoff", 30*time.Second, "wait before the first retry, doubling after each")
+	cmd.Flags().DurationVar(&launcher.retryBackoffMax, "retry-backoff-max", 0, "upper bound for the retry wait (0=unbounded)")
+	cmd.Flags().IntSliceVar(&launcher.successCodes, "success-codes", nil, "exit codes treated as success (default 0)")
+	cmd.Flags().StringVar(&launcher.notifyOn, "notify-on", "", "when to notify: always (default), failure, success or change")
+	cmd.Flags().StringVar(&launcher.notifyTitle, "notify-title", "", "notification title template (default \"Hypnos-{{.Probe}}\")")
+	cmd.Flags().StringVar(&launcher.notifyMessage, "notify-message", "", "notification message template, e.g. \"{{.Status}}: {{.LastLine}}\"")
+	cmd.Flags().BoolVar(&launcher.notifyTimeout, "notify-timeout", false, "notify when the script times out")
+	cmd.Flags().StringVar(&launcher.onSuccess, "on-success", "", "workflow to launch when the probe finishes successfully")
+	cmd.Flags().StringVar(&launcher.onFailure, "on-failure", "", "workflow to launch when the probe finishes with a failure")
+	cmd.Flags().StringArrayVar(&launcher.vars, "var", nil, "template variable KEY=VALUE for script, probe, log and notifications (repeatable)")
+	cmd.Flags().StringSliceVar(&launcher.lineage, "lineage", nil, "probes that chained into this one")
+	horus.CheckErr(cmd.Flags().MarkHidden("lineage"), horus.WithOp("hibernate.init"), horus.WithMessage("hiding --lineage"))
+	cmd.Flags().BoolVar(&launchFlags.replace, "replace", false, "stop a running probe with the same name and take its place")
+	cmd.Flags().BoolVar(&launchFlags.unique, "unique", false, "suffix the probe name (-2, -3, …) when it is already running")
+	cmd.MarkFlagsMutuallyExclusive("replace", "unique")
+	cmd.Flags().BoolVar(&launchFlags.dryRun, "dry-run", false, "print the resolved workflow, worker command and fire times without launching")
+
+	horus.CheckErr(
+		cmd.RegisterFlagCompletionFunc("group", completeWorkflowGroups),
+		horus.WithOp("hibernate.init"),
+		horus.WithMessage("registering group completion"),
+	)
 
 	return cmd
 }
@@ -90,6 +154,23 @@ func HibernateWorkerCmd() *cobra.Command {
 	cmd.Flags().IntVar(&worker.iterations, "iterations", 0, "")
 	cmd.Flags().BoolVar(&worker.notify, "notify-only", false, "only send notification, skip script execution")
 	cmd.Flags().BoolVar(&worker.carbonite, "carbonite", false, "")
+	cmd.Flags().StringVar(&worker.envFile, "env-file", "", "")
+	cmd.Flags().StringVar(&worker.workdir, "workdir", "", "")
+	cmd.Flags().StringVar(&worker.shell, "shell", "", "")
+	cmd.Flags().DurationVar(&worker.timeout, "timeout", 0, "")
+	cmd.Flags().StringVar(&worker.overlap, "overlap", "", "")
+	cmd.Flags().IntVar(&worker.retries, "retries", 0, "")
+	cmd.Flags().DurationVar(&worker.retryBackoff, "retry-backoff", 30*time.Second, "")
+	cmd.Flags().DurationVar(&worker.retryBackoffMax, "retry-backoff-max", 0, "")
+	cmd.Flags().IntSliceVar(&worker.successCodes, "success-codes", nil, "")
+	cmd.Flags().StringVar(&worker.notifyOn, "notify-on", "", "")
+	cmd.Flags().StringVar(&worker.notifyTitle, "notify-title", "", "")
+	cmd.Flags().StringVar(&worker.notifyMessage, "notify-message", "", "")
+	cmd.Flags().BoolVar(&worker.notifyTimeout, "notify-timeout", false, "")
+	cmd.Flags().StringVar(&worker.onSuccess, "on-success", "", "")
+	cmd.Flags().StringVar(&worker.onFailure, "on-failure", "", "")
+	cmd.Flags().StringSliceVar(&worker.lineage, "lineage", nil, "")
+	cmd.Flags().StringArrayVar(&worker.vars, "var", nil, "")
 
 	return cmd
 }
@@ -107,50 +188,52 @@ func preRunHibernate(cmd *cobra.Command, args []string) {
 
 		launcher.config = args[0]
 
-		files, err := domovoi.ReadDir(configDirs.config, rootFlags.verbose)
+		reg, err := loadRegistry()
 		horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
-		var foundV *viper.Viper
-		for _, f := range files {
-			if f.IsDir() || !strings.HasSuffix(f.Name(), ".toml") {
-				continue
-			}
-			path := filepath.Join(configDirs.config, f.Name())
-			v := viper.New()
-			v.SetConfigFile(path)
-			if err := v.ReadInConfig(); err != nil {
-				continue
-			}
-			if v.IsSet("workflows." + launcher.config) {
-				foundV = v
-				break
-			}
-		}
-		if foundV == nil {
+		entry, err := reg.workflow(launcher.config)
+		horus.CheckErr(
+			err,
+			horus.WithOp(op),
+			horus.WithCategory("config_error"),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+		)
+
+		wf := entry.viper()
+		horus.CheckErr(
+			bindWorkflow(cmd, wf, &launcher),
+			horus.WithOp(op),
+			horus.WithCategory("config_error"),
+			horus.WithExitCode(2),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+		)
+
+		// a template workflow may leave the script to the workflows extending it
+		if launcher.script == "" && !launcher.notify {
 			horus.CheckErr(
 				errors.New(""),
-				horus.WithMessage(fmt.Sprintf("workflow %s not found", launcher.config)),
+				horus.WithMessage(fmt.Sprintf("workflow %s has no script", launcher.config)),
 				horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Message) }),
 			)
 		}
 
+		// env keys are case-sensitive, so they bypass viper; flag entries override config entries
+		launcher.env = append(entry.env(), launcher.env...)
+		launcher.vars = append(entry.vars(), launcher.vars...)
+
 		if launcher.probe == "" {
 			launcher.probe = launcher.config
 			horus.CheckErr(cmd.Flags().Set("probe", launcher.probe), horus.WithOp(op), horus.WithMessage("setting default --probe"))
 		}
-
-		wf := foundV.Sub("workflows." + launcher.config)
-		bindFlag(cmd, "script", wf)
-		bindFlag(cmd, "probe", wf)
-		bindFlag(cmd, "log", wf)
-		bindFlag(cmd, "duration", wf)
-		bindFlag(cmd, "recurrent", wf)
-		bindFlag(cmd, "iterations", wf)
-		bindFlag(cmd, "carbonite", wf)
-
 		if !cmd.Flags().Changed("log") {
 			launcher.log = launcher.config
 			horus.CheckErr(cmd.Flags().Set("log", launcher.log), horus.WithOp(op), horus.WithMessage("setting default --log"))
 		}
+	} else if isGroupLaunch(cmd, args) {
+		// GROUP MODE: every workflow labelled with --group is resolved at launch
+		if rootFlags.verbose {
+			fmt.Println("Running on Group mode...")
+		}
+		return
 	} else {
 		// MANUAL MODE: require explicit flags
 		if rootFlags.verbose {
@@ -186,6 +269,35 @@ func preRunHibernate(cmd *cobra.Command, args []string) {
 			horus.WithFormatter(func(he *horus.Herror) string { return chalk.Red.Color(he.Message) }),
 		)
 	}
+
+	// hypnos.toml fills whatever neither a flag nor the workflow set
+	defaults := workflowDefaults()
+	bindFlag(cmd, "group", defaults)
+	bindFlag(cmd, "shell", defaults)
+
+	horus.CheckErr(
+		applyVars(&launcher),
+		horus.WithOp(op),
+		horus.WithCategory("config_error"),
+		horus.WithExitCode(2),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	for _, err := range []error{
+		validateShell(launcher.shell),
+		hypnos.ValidateOverlap(launcher.overlap),
+		hypnos.ValidateNotifyOn(launcher.notifyOn),
+		hypnos.ValidateNotifyTemplate("notify_title", launcher.notifyTitle),
+		hypnos.ValidateNotifyTemplate("notify_message", launcher.notifyMessage),
+	} {
+		horus.CheckErr(
+			err,
+			horus.WithOp(op),
+			horus.WithCategory("config_error"),
+			horus.WithExitCode(2),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+		)
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -193,36 +305,106 @@ func preRunHibernate(cmd *cobra.Command, args []string) {
 func runHibernateLauncher(cmd *cobra.Command, args []string) {
 	const op = "hypnos.hibernate.launch"
 
-	meta := &probeMeta{
-		Probe:      launcher.probe,
-		Group:      launcher.group,
-		Script:     launcher.script,
-		LogPath:    filepath.Join(configDirs.log, launcher.log+".log"),
-		Duration:   launcher.duration,
-		Recurrent:  launcher.recurrent,
-		Iterations: launcher.iterations,
-		Quiescence: time.Now(),
-		Notify:     launcher.notify,
-		Carbonite:  launcher.carbonite,
+	if isGroupLaunch(cmd, args) {
+		runHibernateGroup(launcher.group)
+		return
+	}
+
+	if launchFlags.dryRun {
+		previewLaunch(launcher, collisionPolicy(launchFlags.replace, launchFlags.unique))
+		return
 	}
 
-	pid, err := spawnProbe(meta)
-	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("spawning worker"))
-	meta.PID = pid
+	if err := pruneLogs(); err != nil && rootFlags.verbose {
+		fmt.Printf("warning: pruning logs: %v\n", err)
+	}
+
+	unlock, err := lockLaunches()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("io_error"))
+	horus.CheckErr(
+		claimProbe(&launcher, collisionPolicy(launchFlags.replace, launchFlags.unique)),
+		horus.WithOp(op),
+		horus.WithCategory("probe_conflict"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	meta := newProbeMeta(launcher)
 
-	saveProbeMeta(meta)
+	horus.CheckErr(spawnProbe(meta), horus.WithOp(op), horus.WithMessage("spawning worker"))
+	unlock()
 
-	fmt.Printf("%s: spawned downtime %s with PID %s\n",
+	where := "with PID"
+	if meta.Daemon {
+		where = "in daemon PID"
+	}
+	fmt.Printf("%s: spawned downtime %s %s %s\n",
 		chalk.Green.Color("OK:"),
 		chalk.Green.Color(launcher.probe),
-		chalk.Green.Color(strconv.Itoa(pid)),
+		where,
+		chalk.Green.Color(strconv.Itoa(meta.PID)),
 	)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// runHibernateGroup spawns every configured workflow labelled with group and reports each outcome
+func runHibernateGroup(group string) {
+	const op = "hypnos.hibernate.group"
+
+	reg, err := loadRegistry()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+
+	launched, failed := 0, 0
+	for _, name := range reg.workflowNames() {
+		if reg.workflows[name].group() != group {
+			continue
+		}
+
+		cfg, err := readWorkflow(reg, name, launcher.vars)
+		if err == nil && launchFlags.dryRun {
+			previewLaunch(cfg, collisionPolicy(launchFlags.replace, launchFlags.unique))
+			launched++
+			continue
+		}
+		if err == nil {
+			var meta *probeMeta
+			if meta, err = claimAndSpawn(&cfg, collisionPolicy(launchFlags.replace, launchFlags.unique)); err == nil {
+				launched++
+				fmt.Printf("%s %-20s PID %d\n", chalk.Green.Color("OK:  "), meta.Probe, meta.PID)
+				continue
+			}
+		}
+		failed++
+		fmt.Printf("%s %-20s %v\n", chalk.Red.Color("FAIL:"), name, err)
+	}
+
+	if launched+failed == 0 {
+		horus.CheckErr(
+			errors.New(""),
+			horus.WithMessage(fmt.Sprintf("no workflows in group %s", group)),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Message) }),
+		)
+	}
+
+	verb := "spawned"
+	if launchFlags.dryRun {
+		verb = "would spawn"
+	}
+	fmt.Printf("group %s: %d %s, %d failed\n", group, launched, verb, failed)
+	if failed > 0 {
+		horus.CheckErr(
+			errors.New(""),
+			horus.WithMessage(fmt.Sprintf("%d of %d probes in group %s failed to spawn", failed, launched+failed, group)),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Message) }),
+		)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
 func runHibernateWorker(cmd *cobra.Command, args []string) {
 	const op = "hypnos.hibernate.work"
+	sigs := catchControl()
 
 	logFile := filepath.Join(configDirs.log, worker.log+".log")
 	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
@@ -234,54 +416,38 @@ func runHibernateWorker(cmd *cobra.Command, args []string) {
 		fmt.Fprintln(f, line)
 	}
 
+	worker.env, err = takeWorkerEnv()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("reading worker environment"))
+
 	if worker.carbonite {
 		log("Carbonite mode: running %q as a daemon", worker.script)
-		if err := runAsDaemon(worker.script, f); err != nil {
+		if err := runAsDaemon(worker, f); err != nil {
 			log("Daemon execution failed: %v", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	log("Downtime %q started for %s", worker.probe, worker.duration)
-
-	count := 0
-	for {
-		count++
-
-		done := make(chan struct{})
-
-		runDowntime(worker.duration, func() {
-			if !worker.notify {
-				log("▸ timer fired, executing shell snippet")
-				if err := domovoi.ExecSh(worker.script); err != nil {
-					log("▸ command failed: %v", err)
-				}
-			} else {
-				log("▸ notify-only mode, skipping script execution")
-			}
+	w := newWorker(worker, log)
+	control, cleanup := listenControl(worker.probe, w.Clock, sigs, log)
+	defer cleanup()
 
-			log("▸ timer fired, sending notification")
-			if err := notify("Hypnos-"+worker.probe, "Downtime complete"); err != nil {
-				log("▸ notify failed: %v", err)
-			} else {
-				log("▸ notify succeeded")
-			}
-			close(done)
-		})
-		<-done
-
-		if worker.iterations > 0 && count >= worker.iterations {
-			break
-		}
-		if worker.iterations == 0 && !worker.recurrent {
-			break
-		}
-
-		log("▸ iteration %d complete, restarting timer", count)
+	w.Control = control
+	w.OnDeadline = persistDeadline(worker.probe, w.Clock, log)
+	status := w.Run()
+	// nothing reads control requests past this point, chains included
+	cleanup()
+	if w.Stopped() {
+		return
 	}
 
-	log("Downtime %q fully complete (ran %d times)", worker.probe, count)
+	next := worker.onSuccess
+	if status != hypnos.StatusSuccess {
+		next = worker.onFailure
+	}
+	if next != "" {
+		chainWorkflow(worker, next, log)
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,102 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var historyFlags struct {
+	probe string
+	since string
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func HistoryCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("history", runHistory))
+
+	cmd.Flags().StringVar(&historyFlags.probe, "probe", "", "only this probe")
+	cmd.Flags().StringVar(&historyFlags.since, "since", "", "only runs started after a duration ago (7d, 36h) or a date (2026-01-31)")
+
+	horus.CheckErr(
+		cmd.RegisterFlagCompletionFunc("probe", completeProbeNames),
+		horus.WithOp("history.init"),
+		horus.WithMessage("registering probe completion"),
+	)
+
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runHistory(cmd *cobra.Command, args []string) {
+	const op = "hypnos.history"
+
+	since, err := parseSince(historyFlags.since, time.Now())
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithExitCode(2),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	runs, err := probes().Runs(historyFlags.probe, since)
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("io_error"), horus.WithMessage("reading run history"))
+
+	if len(runs) == 0 {
+		fmt.Println("no runs recorded")
+		return
+	}
+
+	fmt.Printf("%-20s %6s %6s %6s %7s %7s %7s %10s  %s\n",
+		"PROBE", "RUNS", "OK", "FAILED", "TIMEOUT", "SKIPPED", "RETRIES", "MEAN", "LAST")
+
+	var total runStats
+	for _, st := range summarizeRuns(runs) {
+		last := fmt.Sprintf("%s %s", displayTime(st.last.Started).Format("2006-01-02 15:04"), st.last.Status)
+		if st.last.Status != hypnos.StatusSuccess {
+			last = chalk.Red.Color(last)
+		}
+		fmt.Printf("%-20s %6d %6d %6d %7d %7d %7d %10s  %s\n",
+			st.probe, st.runs, st.success, st.failure, st.timeout, st.skipped, st.retries, st.mean().Round(time.Millisecond), last)
+
+		total.runs += st.runs
+		total.success += st.success
+		total.failure += st.failure
+		total.timeout += st.timeout
+		total.skipped += st.skipped
+		total.retries += st.retries
+		total.executed += st.executed
+	}
+
+	fmt.Printf("%-20s %6d %6d %6d %7d %7d %7d %10s\n",
+		"TOTAL", total.runs, total.success, total.failure, total.timeout, total.skipped, total.retries, total.mean().Round(time.Millisecond))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
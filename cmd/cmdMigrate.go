@@ -0,0 +1,82 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var migrateFlags struct {
+	dryRun bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func MigrateCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("migrate", runMigrate))
+	cmd.Flags().BoolVar(&migrateFlags.dryRun, "dry-run", false, "print the moves without touching any file")
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runMigrate(cmd *cobra.Command, args []string) {
+	const op = "hypnos.migrate"
+
+	// the target is what hypnos would use if ~/.hypnos did not exist
+	var target configDir
+	settings, state := xdgRoots(configDirs.home)
+	if configDirs.hypnos != legacyRoot(configDirs.home) {
+		settings, state = configDirs.hypnos, configDirs.state
+	}
+	target.setRoots(settings, state)
+
+	moves, err := planLegacyMigration(configDirs.home, target)
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("migrate_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	for _, m := range moves {
+		fmt.Printf("%s → %s\n", m.from, m.to)
+	}
+	if migrateFlags.dryRun {
+		fmt.Printf("%d move(s) planned, nothing changed\n", len(moves))
+		return
+	}
+
+	horus.CheckErr(
+		applyLegacyMigration(configDirs.home, moves),
+		horus.WithOp(op),
+		horus.WithCategory("io_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+	fmt.Printf("%s legacy layout migrated (config: %s, state: %s)\n", chalk.Green.Color("OK:"), target.hypnos, target.state)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
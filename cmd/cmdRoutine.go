@@ -0,0 +1,250 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var routineFlags struct {
+	probe   string
+	log     string
+	group   string
+	routine string
+	replace bool
+	unique  bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func RoutineCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("routine", nil))
+	cmd.AddCommand(RoutineStartCmd(), RoutineListCmd())
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func RoutineStartCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("routine-start", runRoutineStart,
+		domovoi.WithArgs(cobra.ExactArgs(1)),
+		domovoi.WithValidArgsFunction(completeRoutineNames),
+	))
+
+	cmd.Flags().StringVar(&routineFlags.probe, "probe", "", "instance name (default: routine name)")
+	cmd.Flags().StringVar(&routineFlags.log, "log", "", "log file basename (default: routine name)")
+	cmd.Flags().BoolVar(&routineFlags.replace, "replace", false, "stop a running probe with the same name and take its place")
+	cmd.Flags().BoolVar(&routineFlags.unique, "unique", false, "suffix the probe name (-2, -3, …) when it is already running")
+	cmd.MarkFlagsMutuallyExclusive("replace", "unique")
+
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func RoutineListCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("routine-list", runRoutineList))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func RoutineWorkerCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("routine-worker", runRoutineWorker))
+
+	cmd.Flags().StringVar(&routineFlags.probe, "probe", "", "instance name")
+	cmd.Flags().StringVar(&routineFlags.routine, "routine", "", "routine to drive")
+	cmd.Flags().StringVar(&routineFlags.log, "log", "", "log basename")
+	cmd.Flags().StringVar(&routineFlags.group, "group", "", "group label for this probe")
+
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runRoutineStart(cmd *cobra.Command, args []string) {
+	const op = "hypnos.routine.start"
+
+	name := args[0]
+	reg, err := loadRegistry()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+	spec, err := readRoutine(reg, name)
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("config_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	probe := routineFlags.probe
+	if probe == "" {
+		probe = name
+	}
+	logName := routineFlags.log
+	if logName == "" {
+		logName = name
+	}
+
+	// held until the PID is recorded: metadata without one does not count as a live probe
+	unlock, err := lockLaunches()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("io_error"))
+
+	claim := configPaths{probe: probe, log: logName}
+	horus.CheckErr(
+		claimProbe(&claim, collisionPolicy(routineFlags.replace, routineFlags.unique)),
+		horus.WithOp(op),
+		horus.WithCategory("probe_conflict"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+	probe, logName = claim.probe, claim.log
+
+	group := spec.group
+	if group == "" {
+		group = settings.GetString("default_group")
+	}
+
+	meta := &probeMeta{
+		Probe:      probe,
+		Group:      group,
+		LogPath:    filepath.Join(configDirs.log, logName+".log"),
+		Duration:   spec.steps[0].duration,
+		Recurrent:  spec.repeat == 0,
+		Iterations: spec.repeat,
+		Quiescence: time.Now(),
+		Routine:    name,
+	}
+
+	// metadata exists before the worker starts, since the worker records its steps there
+	saveProbeMeta(meta)
+
+	pid, err := spawnRoutine(meta)
+	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("spawning routine worker"))
+	horus.CheckErr(
+		updateProbeMeta(probe, func(m *probeMeta) { m.PID = pid }),
+		horus.WithOp(op),
+		horus.WithCategory("io_error"),
+		horus.WithMessage("recording routine PID"),
+	)
+	unlock()
+
+	fmt.Printf("%s: started routine %s with PID %s\n",
+		chalk.Green.Color("OK:"),
+		chalk.Green.Color(probe),
+		chalk.Green.Color(strconv.Itoa(pid)),
+	)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runRoutineList(cmd *cobra.Command, args []string) {
+	const op = "hypnos.routine.list"
+
+	reg, err := loadRegistry()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+
+	names := reg.routineNames()
+	if len(names) == 0 {
+		fmt.Printf("no routines defined in %s\n", configDirs.config)
+		return
+	}
+
+	for _, name := range names {
+		spec, err := readRoutine(reg, name)
+		if err != nil {
+			fmt.Printf("%-20s %s\n", name, chalk.Red.Color(err.Error()))
+			continue
+		}
+		var steps []string
+		for _, st := range spec.steps {
+			steps = append(steps, fmt.Sprintf("%s %s", st.workflow, st.duration))
+		}
+		line := strings.Join(steps, " → ")
+		if spec.longBreak != nil {
+			line += fmt.Sprintf(" (every %d: %s %s)", spec.longEvery, spec.longBreak.workflow, spec.longBreak.duration)
+		}
+		repeat := "∞"
+		if spec.repeat > 0 {
+			repeat = strconv.Itoa(spec.repeat)
+		}
+		fmt.Printf("%-20s x%-4s %s\n", name, repeat, line)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runRoutineWorker(cmd *cobra.Command, args []string) {
+	const op = "hypnos.routine.work"
+	// routine steps are not controlled by signal, and neither are their scripts
+	resetControl()
+
+	logFile := filepath.Join(configDirs.log, routineFlags.log+".log")
+	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("opening log file"))
+	defer f.Close()
+
+	log := func(format string, a ...any) {
+		line := fmt.Sprintf(format, a...)
+		fmt.Fprintln(f, line)
+	}
+
+	reg, err := loadRegistry()
+	if err != nil {
+		log("Reading config dir failed: %v", err)
+		os.Exit(1)
+	}
+	spec, err := readRoutine(reg, routineFlags.routine)
+	if err != nil {
+		log("Routine %q failed to load: %v", routineFlags.routine, err)
+		os.Exit(1)
+	}
+
+	log("Routine %q started as probe %q", spec.name, routineFlags.probe)
+	runRoutine(spec, routineFlags.probe, routineFlags.group, log)
+	log("Routine %q fully complete", spec.name)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func completeRoutineNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	reg, err := loadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var opts []string
+	for _, name := range reg.routineNames() {
+		if strings.HasPrefix(name, toComplete) {
+			opts = append(opts, name)
+		}
+	}
+	return opts, cobra.ShellCompDirectiveNoFileComp
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -20,9 +20,6 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
@@ -43,49 +40,92 @@ func ScanCmd() *cobra.Command {
 func runScan(cmd *cobra.Command, args []string) {
 	const op = "hypnos.scan"
 
-	entries, err := domovoi.ReadDir(configDirs.probe, rootFlags.verbose)
-	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("reading probe directory"))
+	names, err := probes().Names()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("reading probe store"))
 
-	if len(entries) == 0 {
-		fmt.Println("no probes hibernating in ~/.hypnos/meta")
+	if len(names) == 0 {
+		fmt.Printf("no probes hibernating in %s\n", configDirs.probe)
 		return
 	}
 
 	fmt.Printf(
-		"%-20s %-15s %-6s %-20s %s\n",
-		"NAME", "GROUP", "PID", "INVOKED", "STATUS",
+		"%-20s %-15s %-6s %-20s %-12s %s\n",
+		"NAME", "GROUP", "PID", "INVOKED", "DURATION", "STATUS",
 	)
 
-	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+	for _, name := range names {
+		meta, err := readProbeMeta(name)
+		if err != nil {
+			fmt.Printf("%-20s %s\n", name, chalk.Red.Color(err.Error()))
 			continue
 		}
-		name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
-
-		meta := loadProbeMeta(name)
 
+		// a worker with a control socket reports its schedule; others are judged by ps alone
 		status := chalk.Red.Color("mortem")
-		stateOut, err := exec.Command("ps", "-o", "state=", "-p", strconv.Itoa(meta.PID)).Output()
-		if err == nil {
-			state := strings.TrimSpace(string(stateOut))
+		next := meta.NextFire
+		state := processState(meta.PID)
+		if state != "" {
+			live := probeState(meta)
 			switch {
+			case live != nil && live.Paused:
+				status = chalk.Yellow.Color("stasis") + " " + chalk.Cyan.Color(fmt.Sprintf("%s left", live.Remaining.Round(time.Second)))
+				next = time.Time{}
 			case strings.HasPrefix(state, "T"):
 				status = chalk.Yellow.Color("stasis")
 			default:
 				status = chalk.Green.Color("hibernating")
 			}
+			if live != nil && !live.Paused {
+				next = live.Next
+			}
 		}
 
-		invoked := meta.Quiescence.Format("2006-01-02 15:04:05")
+		invoked := displayTime(meta.Quiescence).Format("2006-01-02 15:04:05")
 
 		age := time.Since(meta.Quiescence).Truncate(time.Second)
 		duration := fmt.Sprintf("%s (%s ago)", meta.Duration, age)
 
+		// routines report the step in progress, timed from when that step started
+		if meta.Routine != "" && meta.Step != "" {
+			age = time.Since(meta.StepStarted).Truncate(time.Second)
+			duration = fmt.Sprintf("%s (%s ago)", meta.Duration, age)
+			status += " " + chalk.Cyan.Color(meta.Step)
+		}
+
+		// a live worker keeps its next firing current, including snoozes and extensions
+		if meta.Routine == "" && !next.IsZero() && state != "" {
+			status += " " + chalk.Cyan.Color("next "+nextFireLabel(next))
+		}
+
+		group := meta.Group
+		if group == "" {
+			group = "-"
+		}
+
 		fmt.Printf(
-			"%-20s %-6d %-20s %-12s %s\n",
-			meta.Probe, meta.PID, invoked, duration, status,
+			"%-20s %-15s %-6d %-20s %-12s %s\n",
+			meta.Probe, group, meta.PID, invoked, duration, status,
 		)
+
+		// the resolved script and vars are what the worker actually runs
+		if rootFlags.verbose {
+			fmt.Printf("    script: %s\n", meta.Script)
+			if len(meta.Vars) > 0 {
+				fmt.Printf("    vars:   %s\n", strings.Join(meta.Vars, " "))
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// nextFireLabel shows the time of day for firings due today and the date as well for later ones
+func nextFireLabel(t time.Time) string {
+	t, now := displayTime(t), displayTime(time.Now())
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format(time.TimeOnly)
 	}
+	return t.Format("2006-01-02 15:04")
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,92 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func SnoozeCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("snooze", runSnooze,
+		domovoi.WithArgs(cobra.ExactArgs(2)),
+		domovoi.WithValidArgsFunction(completeProbeNames),
+	))
+}
+
+func ExtendCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("extend", runExtend,
+		domovoi.WithArgs(cobra.ExactArgs(2)),
+		domovoi.WithValidArgsFunction(completeProbeNames),
+	))
+	// flags end at the probe name, so a negative span such as -5m is an argument
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runSnooze postpones the next firing to a span from now
+func runSnooze(cmd *cobra.Command, args []string) {
+	reschedule("hypnos.snooze", args[0], func(time.Time) (time.Time, error) {
+		span, err := parseSpan(args[1])
+		if err != nil || span <= 0 {
+			return time.Time{}, fmt.Errorf("invalid snooze %q: use a positive duration such as 10m", args[1])
+		}
+		return time.Now().Add(wallSpan(span)), nil
+	})
+}
+
+// runExtend shifts the next firing by a signed span, +15m later or -5m sooner
+func runExtend(cmd *cobra.Command, args []string) {
+	reschedule("hypnos.extend", args[0], func(current time.Time) (time.Time, error) {
+		span, err := parseSpan(strings.TrimPrefix(args[1], "+"))
+		if err != nil || span == 0 {
+			return time.Time{}, fmt.Errorf("invalid extension %q: use a signed duration such as +15m or -5m", args[1])
+		}
+		if current.IsZero() {
+			return time.Time{}, fmt.Errorf("probe %s does not report its next firing; relaunch it to extend it", args[0])
+		}
+		return current.Add(wallSpan(span)), nil
+	})
+}
+
+func reschedule(op, name string, next func(time.Time) (time.Time, error)) {
+	meta, err := rescheduleProbe(name, next)
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("control_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	in := time.Until(meta.NextFire).Round(time.Second)
+	fmt.Printf("%s probe %q now fires at %s (in %s)\n", chalk.Green.Color("OK:"), meta.Probe,
+		displayTime(meta.NextFire).Format(time.DateTime), max(in, 0))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var stasisFlags struct {
+	resume bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func StasisCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("stasis", runStasis,
+		domovoi.WithArgs(cobra.ExactArgs(1)),
+		domovoi.WithValidArgsFunction(completeProbeNames),
+	))
+	cmd.Flags().BoolVar(&stasisFlags.resume, "resume", false, "restart the countdown with the time that was left")
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runStasis(cmd *cobra.Command, args []string) {
+	const op = "hypnos.stasis.pause"
+
+	meta, state, err := pauseProbe(args[0], stasisFlags.resume)
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("control_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	ok := chalk.Green.Color("OK:")
+	switch {
+	case state == nil:
+		fmt.Printf("%s sent SIGCONT to stopped PID %d for %q (firings due meanwhile happen now)\n", ok, meta.PID, meta.Probe)
+	case stasisFlags.resume:
+		fmt.Printf("%s probe %q resumed, next firing at %s\n", ok, meta.Probe, displayTime(state.Next).Format(time.DateTime))
+	default:
+		fmt.Printf("%s probe %q paused with %s left\n", ok, meta.Probe, state.Remaining.Round(time.Second))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
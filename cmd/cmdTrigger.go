@@ -0,0 +1,67 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var triggerFlags struct {
+	reset bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TriggerCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("trigger", runTrigger,
+		domovoi.WithArgs(cobra.ExactArgs(1)),
+		domovoi.WithValidArgsFunction(completeProbeNames),
+	))
+	cmd.Flags().BoolVar(&triggerFlags.reset, "reset", false, "restart the countdown from now instead of keeping the schedule")
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runTrigger(cmd *cobra.Command, args []string) {
+	const op = "hypnos.trigger"
+
+	meta, err := triggerProbe(args[0], triggerFlags.reset)
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("control_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	effect := "schedule kept"
+	if triggerFlags.reset {
+		effect = "countdown reset"
+	}
+	fmt.Printf("%s triggered probe %q (PID %d, %s)\n", chalk.Green.Color("OK:"), meta.Probe, meta.PID, effect)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
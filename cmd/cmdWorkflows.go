@@ -0,0 +1,138 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DanielRivasMD/domovoi"
+	"github.com/DanielRivasMD/horus"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func WorkflowsCmd() *cobra.Command {
+	cmd := horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("workflows", nil))
+	cmd.AddCommand(WorkflowsListCmd(), WorkflowsShowCmd())
+	return cmd
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func WorkflowsListCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("workflows-list", runWorkflowsList))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func WorkflowsShowCmd() *cobra.Command {
+	return horus.Must(horus.Must(domovoi.GlobalDocs()).MakeCmd("workflows-show", runWorkflowsShow,
+		domovoi.WithArgs(cobra.ExactArgs(1)),
+		domovoi.WithValidArgsFunction(completeWorkflowNames),
+	))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runWorkflowsList(cmd *cobra.Command, args []string) {
+	const op = "hypnos.workflows.list"
+
+	reg, err := loadRegistry()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+	reportBrokenFiles(reg)
+
+	names := reg.workflowNames()
+	if len(names) == 0 {
+		fmt.Printf("no workflows defined in %s\n", configDirs.config)
+		return
+	}
+
+	fmt.Printf("%-20s %-12s %-10s %s\n", "NAME", "GROUP", "DURATION", "FILE")
+	for _, name := range names {
+		entry := reg.workflows[name]
+		duration, _ := entry.table["duration"].(string)
+		line := fmt.Sprintf("%-20s %-12s %-10s %s", name, entry.group(), duration, filepath.Base(entry.file))
+		if entry.err != nil {
+			line += chalk.Red.Color("  " + entry.err.Error())
+		}
+		if len(entry.shadowed) > 0 {
+			line += chalk.Yellow.Color(fmt.Sprintf("  (shadows %s)", baseNames(entry.shadowed)))
+		}
+		fmt.Println(line)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func runWorkflowsShow(cmd *cobra.Command, args []string) {
+	const op = "hypnos.workflows.show"
+
+	reg, err := loadRegistry()
+	horus.CheckErr(err, horus.WithOp(op), horus.WithCategory("env_error"), horus.WithMessage("reading config dir"))
+
+	entry, err := reg.workflow(args[0])
+	horus.CheckErr(
+		err,
+		horus.WithOp(op),
+		horus.WithCategory("config_error"),
+		horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+	)
+
+	out, err := toml.Marshal(map[string]any{"workflows": map[string]any{entry.name: entry.table}})
+	horus.CheckErr(err, horus.WithOp(op), horus.WithMessage("encoding workflow"))
+
+	fmt.Printf("# from %s\n", entry.file)
+	if len(entry.parents) > 0 {
+		fmt.Printf("# extends %s\n", strings.Join(entry.parents, " → "))
+	}
+	if len(reg.defaults[entry.file]) > 0 {
+		fmt.Printf("# with [defaults] of %s\n", filepath.Base(entry.file))
+	}
+	for _, path := range entry.shadowed {
+		fmt.Printf("# shadows %s\n", path)
+	}
+	fmt.Print(strings.TrimPrefix(string(out), "[workflows]\n"))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// reportBrokenFiles warns about config files left out of the registry
+func reportBrokenFiles(reg *configRegistry) {
+	for _, path := range reg.files {
+		if err, ok := reg.broken[path]; ok {
+			fmt.Fprintf(os.Stderr, "%s %s skipped: %v\n", chalk.Yellow.Color("WARN:"), filepath.Base(path), err)
+		}
+	}
+}
+
+func baseNames(paths []string) string {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -20,7 +20,7 @@ package cmd
 
 import (
 	"embed"
-	"path/filepath"
+	"os"
 	"sync"
 
 	"github.com/DanielRivasMD/domovoi"
@@ -48,17 +48,22 @@ var (
 	onceRoot  sync.Once
 	rootCmd   *cobra.Command
 	rootFlags struct {
-		verbose     bool
+		verbose   bool
+		home      string
+		timeScale float64
 	}
 	configDirs configDir
 )
 
 type configDir struct {
-	home   string
-	hypnos string
-	config string
-	log    string
-	probe  string
+	home    string
+	hypnos  string
+	state   string
+	config  string
+	log     string
+	probe   string
+	history string
+	run     string
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -83,9 +88,12 @@ func GetRootCmd() *cobra.Command {
 		horus.CheckErr(err)
 
 		rootCmd.PersistentFlags().BoolVarP(&rootFlags.verbose, "verbose", "v", false, "Enable verbose diagnostics")
+		rootCmd.PersistentFlags().StringVar(&rootFlags.home, "home", "", "keep all hypnos files under this directory (env HYPNOS_HOME)")
+		rootCmd.PersistentFlags().Float64Var(&rootFlags.timeScale, "time-scale", 1, "run every schedule this many times faster (debugging)")
+		horus.CheckErr(rootCmd.PersistentFlags().MarkHidden("time-scale"), horus.WithMessage("hiding --time-scale"))
 		rootCmd.Version = VERSION
 
-		cobra.OnInitialize(initConfigDirs)
+		cobra.OnInitialize(initConfigDirs, initSettings, initTimeScale)
 	})
 	return rootCmd
 }
@@ -104,10 +112,15 @@ func initConfigDirs() {
 		horus.CheckErr(e, horus.WithCategory("init_error"), horus.WithMessage("getting home directory"))
 		return h
 	}()
-	configDirs.hypnos = filepath.Join(configDirs.home, ".hypnos")
-	configDirs.config = filepath.Join(configDirs.hypnos, "config")
-	configDirs.log = filepath.Join(configDirs.hypnos, "log")
-	configDirs.probe = filepath.Join(configDirs.hypnos, "probe")
+
+	settings, state, err := resolveRoots(configDirs.home, rootFlags.home)
+	horus.CheckErr(err, horus.WithCategory("init_error"), horus.WithMessage("resolving hypnos directories"))
+	configDirs.setRoots(settings, state)
+
+	// spawned workers and chained launches must see the same root
+	if rootFlags.home != "" {
+		horus.CheckErr(os.Setenv("HYPNOS_HOME", settings), horus.WithCategory("init_error"), horus.WithMessage("exporting HYPNOS_HOME"))
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -118,11 +131,23 @@ func BuildCommands() {
 		CompletionCmd(),
 		IdentityCmd(),
 
+		CheckCmd(),
+		ConfigCmd(),
 		CryostasisCmd(),
+		DaemonCmd(),
+		ExtendCmd(),
 		HibernateLauncherCmd(),
 		HibernateWorkerCmd(),
+		HistoryCmd(),
+		MigrateCmd(),
 		PrimeCmd(),
+		RoutineCmd(),
+		RoutineWorkerCmd(),
 		ScanCmd(),
+		SnoozeCmd(),
+		StasisCmd(),
+		TriggerCmd(),
+		WorkflowsCmd(),
 	)
 }
 
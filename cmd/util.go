@@ -19,15 +19,11 @@ package cmd
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/DanielRivasMD/domovoi"
 	"github.com/DanielRivasMD/horus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,7 +32,9 @@ import (
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type probeMeta struct {
+	Version    int           `json:"version"`
 	Probe      string        `json:"probe"`
+	Workflow   string        `json:"workflow,omitempty"`
 	Group      string        `json:"group"`
 	Script     string        `json:"script"`
 	LogPath    string        `json:"log_path"`
@@ -45,18 +43,49 @@ type probeMeta struct {
 	Iterations int           `json:"iterations"`
 	PID        int           `json:"pid"`
 	Quiescence time.Time     `json:"quiescence"`
+	NextFire   time.Time     `json:"next_fire,omitempty"`
 	Notify     bool          `json:"notify"`
 	Carbonite  bool          `json:"carbonite"`
+	Daemon     bool          `json:"daemon,omitempty"`
+	Env        []string      `json:"env,omitempty"`
+	EnvFile    string        `json:"env_file,omitempty"`
+	Workdir    string        `json:"workdir,omitempty"`
+	Shell      string        `json:"shell,omitempty"`
+	Timeout    time.Duration `json:"timeout,omitempty"`
+	Overlap    string        `json:"overlap,omitempty"`
+
+	Retries         int           `json:"retries,omitempty"`
+	RetryBackoff    time.Duration `json:"retry_backoff,omitempty"`
+	RetryBackoffMax time.Duration `json:"retry_backoff_max,omitempty"`
+	SuccessCodes    []int         `json:"success_codes,omitempty"`
+
+	NotifyOn      string `json:"notify_on,omitempty"`
+	NotifyTitle   string `json:"notify_title,omitempty"`
+	NotifyMessage string `json:"notify_message,omitempty"`
+	NotifyTimeout bool   `json:"notify_timeout,omitempty"`
+
+	OnSuccess string   `json:"on_success,omitempty"`
+	OnFailure string   `json:"on_failure,omitempty"`
+	Lineage   []string `json:"lineage,omitempty"`
+
+	Vars []string `json:"vars,omitempty"`
+
+	Routine     string    `json:"routine,omitempty"`
+	Step        string    `json:"step,omitempty"`
+	Cycle       int       `json:"cycle,omitempty"`
+	StepStarted time.Time `json:"step_started,omitempty"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // bindFlag reads a value from a Viper config and sets the corresponding flag if not already changed
+// hyphenated flags are read from their snake_case key, e.g. --env-file from env_file
 func bindFlag(cmd *cobra.Command, flagName string, cfg *viper.Viper) {
 	const op = "cli.bindFlag"
 	flags := cmd.Flags()
+	key := strings.ReplaceAll(flagName, "-", "_")
 
-	if flags.Changed(flagName) || !cfg.IsSet(flagName) {
+	if flags.Changed(flagName) || !cfg.IsSet(key) {
 		return
 	}
 
@@ -68,13 +97,13 @@ func bindFlag(cmd *cobra.Command, flagName string, cfg *viper.Viper) {
 	var raw string
 	switch f.Value.Type() {
 	case "string":
-		raw = cfg.GetString(flagName)
+		raw = cfg.GetString(key)
 	case "int":
-		raw = strconv.Itoa(cfg.GetInt(flagName))
+		raw = strconv.Itoa(cfg.GetInt(key))
 	case "bool":
-		raw = strconv.FormatBool(cfg.GetBool(flagName))
+		raw = strconv.FormatBool(cfg.GetBool(key))
 	case "duration":
-		val := cfg.GetString(flagName)
+		val := cfg.GetString(key)
 		if _, err := time.ParseDuration(val); err == nil {
 			raw = val
 		} else {
@@ -90,9 +119,15 @@ func bindFlag(cmd *cobra.Command, flagName string, cfg *viper.Viper) {
 			return
 		}
 	case "float64":
-		raw = strconv.FormatFloat(cfg.GetFloat64(flagName), 'f', -1, 64)
+		raw = strconv.FormatFloat(cfg.GetFloat64(key), 'f', -1, 64)
+	case "intSlice":
+		var codes []string
+		for _, n := range cfg.GetIntSlice(key) {
+			codes = append(codes, strconv.Itoa(n))
+		}
+		raw = strings.Join(codes, ",")
 	default:
-		raw = cfg.GetString(flagName)
+		raw = cfg.GetString(key)
 	}
 
 	if err := flags.Set(flagName, raw); err != nil {
@@ -113,41 +148,67 @@ func bindFlag(cmd *cobra.Command, flagName string, cfg *viper.Viper) {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// resolveExtends layers a workflow over what it inherits before bindFlag reads it: the [defaults]
+// of its own file first, then its resolved `extends` parent, then its own keys. lookup returns a
+// workflow's table as written and the defaults of the file defining it
+func resolveExtends(name string, lookup func(string) (map[string]any, map[string]any, bool), stack []string) (map[string]any, []string, error) {
+	for _, seen := range stack {
+		if seen == name {
+			return nil, nil, fmt.Errorf("extends cycle %s", strings.Join(append(stack, name), " → "))
+		}
+	}
+
+	raw, defaults, ok := lookup(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("extends unknown workflow %q", name)
+	}
+
+	resolved := mergeTables(nil, defaults)
+	var chain []string
+	if parent, _ := raw["extends"].(string); parent != "" {
+		inherited, parents, err := resolveExtends(parent, lookup, append(stack, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		resolved = mergeTables(resolved, inherited)
+		chain = append([]string{parent}, parents...)
+	}
+	resolved = mergeTables(resolved, raw)
+	delete(resolved, "extends")
+
+	return resolved, chain, nil
+}
+
+// mergeTables returns base overlaid with over; nested tables such as env merge key by key
+func mergeTables(base, over map[string]any) map[string]any {
+	out := copyTable(base)
+	for k, v := range over {
+		sub, isTable := v.(map[string]any)
+		prev, wasTable := out[k].(map[string]any)
+		if isTable && wasTable {
+			out[k] = mergeTables(prev, sub)
+			continue
+		}
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
 func saveProbeMeta(meta *probeMeta) {
 	const op = "probe.saveMeta"
 
 	horus.CheckErr(
-		domovoi.CreateDir(configDirs.probe, rootFlags.verbose),
+		probes().Save(meta),
 		horus.WithOp(op),
 		horus.WithCategory("io_error"),
-		horus.WithMessage("creating probe directory"),
-		horus.WithDetails(map[string]any{
-			"dir": configDirs.probe,
-		}),
-	)
-
-	data, err := json.MarshalIndent(meta, "", "  ")
-	horus.CheckErr(
-		err,
-		horus.WithOp(op),
-		horus.WithCategory("encode_error"),
-		horus.WithMessage("marshaling probe metadata"),
+		horus.WithMessage("writing probe metadata"),
 		horus.WithDetails(map[string]any{
 			"probe": meta.Probe,
 			"group": meta.Group,
 		}),
 	)
-
-	path := filepath.Join(configDirs.probe, meta.Probe+".json")
-	horus.CheckErr(
-		os.WriteFile(path, data, 0o644),
-		horus.WithOp(op),
-		horus.WithCategory("io_error"),
-		horus.WithMessage("writing probe metadata file"),
-		horus.WithDetails(map[string]any{
-			"path": path,
-		}),
-	)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -155,71 +216,45 @@ func saveProbeMeta(meta *probeMeta) {
 func loadProbeMeta(name string) *probeMeta {
 	const op = "hypnos.loadProbeMeta"
 
-	path := filepath.Join(configDirs.probe, name+".json")
-
-	data, err := os.ReadFile(path)
+	meta, err := probes().Load(name)
 	horus.CheckErr(
 		err,
 		horus.WithOp(op),
 		horus.WithCategory("io_error"),
-		horus.WithMessage("reading probe metadata file"),
+		horus.WithMessage("reading probe metadata"),
 		horus.WithDetails(map[string]any{
-			"path": path,
 			"name": name,
 		}),
 	)
 
-	var meta probeMeta
-	horus.CheckErr(
-		json.Unmarshal(data, &meta),
-		horus.WithOp(op),
-		horus.WithCategory("decode_error"),
-		horus.WithMessage("unmarshaling probe metadata"),
-		horus.WithDetails(map[string]any{
-			"path": path,
-			"name": name,
-		}),
-	)
-
-	return &meta
+	return meta
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func listProbeMetaFiles() []string {
-	var files []string
-	entries, err := os.ReadDir(configDirs.probe)
-	if err != nil {
-		return files
-	}
-	for _, fi := range entries {
-		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
-			continue
-		}
-		files = append(files, filepath.Join(configDirs.probe, fi.Name()))
-	}
-	return files
+// updateProbeMeta lets a running worker rewrite its own metadata without exiting on failure
+func updateProbeMeta(name string, update func(*probeMeta)) error {
+	return probes().Update(name, update)
+}
+
+// readProbeMeta is loadProbeMeta for callers that handle a missing or broken file themselves
+func readProbeMeta(name string) (*probeMeta, error) {
+	return probes().Load(name)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func stripProbeName(metaFile string) string {
-	base := filepath.Base(metaFile)
-	return strings.TrimSuffix(base, ".json")
+// listProbeNames returns every stored probe, or none when the store cannot be read
+func listProbeNames() []string {
+	names, _ := probes().Names()
+	return names
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func matchProbeGroup(metaFile string, group string) bool {
-	data, err := os.ReadFile(metaFile)
-	if err != nil {
-		return false
-	}
-	var m probeMeta
-	if err := json.Unmarshal(data, &m); err != nil {
-		return false
-	}
-	return m.Group == group
+func matchProbeGroup(name string, group string) bool {
+	meta, err := readProbeMeta(name)
+	return err == nil && meta.Group == group
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -227,15 +262,11 @@ func matchProbeGroup(metaFile string, group string) bool {
 func completeProbeNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	var names []string
 
-	files, err := os.ReadDir(configDirs.probe)
+	all, err := probes().Names()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
-	for _, fi := range files {
-		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
-			continue
-		}
-		name := strings.TrimSuffix(fi.Name(), ".json")
+	for _, name := range all {
 		if strings.HasPrefix(name, toComplete) {
 			names = append(names, name)
 		}
@@ -247,15 +278,11 @@ func completeProbeNames(cmd *cobra.Command, args []string, toComplete string) ([
 
 func completeProbeGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	groups := make(map[string]struct{})
-	for _, metaFile := range listProbeMetaFiles() {
-		data, err := os.ReadFile(metaFile)
+	for _, name := range listProbeNames() {
+		m, err := readProbeMeta(name)
 		if err != nil {
 			continue
 		}
-		var m probeMeta
-		if err := json.Unmarshal(data, &m); err != nil {
-			continue
-		}
 		if m.Group != "" && strings.HasPrefix(m.Group, toComplete) {
 			groups[m.Group] = struct{}{}
 		}
@@ -270,39 +297,39 @@ func completeProbeGroups(cmd *cobra.Command, args []string, toComplete string) (
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func completeWorkflowNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	files, err := os.ReadDir(configDirs.config)
+	reg, err := loadRegistry()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
 
-	seen := make(map[string]struct{})
 	var opts []string
-
-	for _, fi := range files {
-		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".toml") {
-			continue
-		}
-		path := filepath.Join(configDirs.config, fi.Name())
-		v := viper.New()
-		v.SetConfigFile(path)
-		if err := v.ReadInConfig(); err != nil {
-			continue
-		}
-		for _, key := range v.AllKeys() {
-			if wf, ok := strings.CutPrefix(key, "workflows."); ok {
-				parts := strings.Split(wf, ".")
-				name := parts[0]
-				if _, exists := seen[name]; exists {
-					continue
-				}
-				if strings.HasPrefix(name, toComplete) {
-					opts = append(opts, name)
-					seen[name] = struct{}{}
-				}
-			}
+	for _, name := range reg.workflowNames() {
+		if strings.HasPrefix(name, toComplete) {
+			opts = append(opts, name)
 		}
 	}
 	return opts, cobra.ShellCompDirectiveNoFileComp
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func completeWorkflowGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	reg, err := loadRegistry()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	groups := make(map[string]struct{})
+	for _, entry := range reg.workflows {
+		if g := entry.group(); g != "" && strings.HasPrefix(g, toComplete) {
+			groups[g] = struct{}{}
+		}
+	}
+	var out []string
+	for g := range groups {
+		out = append(out, g)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
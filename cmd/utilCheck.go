@@ -0,0 +1,419 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// checkIssue is one problem found in a config file
+type checkIssue struct {
+	file string
+	line int
+	msg  string
+}
+
+func (i checkIssue) String() string {
+	if i.line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.file, i.line, i.msg)
+	}
+	return fmt.Sprintf("%s: %s", i.file, i.msg)
+}
+
+// checkedTable records where a workflow was first defined, to report duplicates across files
+type checkedTable struct {
+	file string
+	line int
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// checkConfigFiles validates every file, reporting workflows defined in more than one of them.
+// Workflow rules run on the resolved tables, so context files only serve as `extends` targets
+func checkConfigFiles(paths, context []string) []checkIssue {
+	var issues []checkIssue
+	seen := make(map[string]checkedTable)
+	lines := make(map[string]map[string]int)
+
+	for _, path := range paths {
+		fileIssues, defined, fileLines := checkConfigFile(path)
+		issues = append(issues, fileIssues...)
+		lines[path] = fileLines
+
+		for _, name := range sortedKeys(defined) {
+			line := defined[name]
+			if first, dup := seen[name]; dup {
+				issues = append(issues, checkIssue{path, line,
+					fmt.Sprintf("workflow %q is already defined at %s:%d", name, first.file, first.line)})
+				continue
+			}
+			seen[name] = checkedTable{path, line}
+		}
+	}
+
+	reg := loadRegistryFiles(append(append([]string{}, paths...), context...))
+	parents := make(map[string]bool)
+	for _, entry := range reg.workflows {
+		for _, parent := range entry.parents {
+			parents[parent] = true
+		}
+	}
+
+	for _, name := range reg.workflowNames() {
+		entry := reg.workflows[name]
+		fileLines, checked := lines[entry.file]
+		if !checked {
+			continue
+		}
+		prefix := "workflows." + name
+		lineOfKey := func(key string) int {
+			if line, ok := fileLines[prefix+"."+key]; ok && key != "" {
+				return line
+			}
+			return fileLines[prefix]
+		}
+
+		if entry.err != nil {
+			issues = append(issues, checkIssue{entry.file, lineOfKey("extends"), entry.err.Error()})
+			continue
+		}
+		for _, msg := range checkWorkflowRules(entry.table) {
+			// a workflow others extend may be a template that leaves the script to its children
+			if msg.key == "" && parents[name] {
+				continue
+			}
+			issues = append(issues, checkIssue{entry.file, lineOfKey(msg.key), fmt.Sprintf("workflow %q: %s", name, msg.text)})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].file != issues[j].file {
+			return issues[i].file < issues[j].file
+		}
+		return issues[i].line < issues[j].line
+	})
+	return issues
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// checkConfigFile validates the keys of one file, returning its issues, the line of each workflow
+// it defines and the line of every key
+func checkConfigFile(path string) ([]checkIssue, map[string]int, map[string]int) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []checkIssue{{file: path, msg: err.Error()}}, nil, nil
+	}
+
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		var de *toml.DecodeError
+		if errors.As(err, &de) {
+			row, _ := de.Position()
+			return []checkIssue{{path, row, de.Error()}}, nil, nil
+		}
+		return []checkIssue{{file: path, msg: err.Error()}}, nil, nil
+	}
+
+	lines := keyLines(data)
+	var issues []checkIssue
+	report := func(key, format string, a ...any) {
+		key = strings.TrimSuffix(key, ".")
+		issues = append(issues, checkIssue{path, lines[key], fmt.Sprintf(format, a...)})
+	}
+
+	if raw, ok := doc["defaults"]; ok {
+		defaults, isTable := raw.(map[string]any)
+		if !isTable {
+			report("defaults", "[defaults] must be a table")
+		}
+		for _, msg := range checkTable(defaults, workflowKeys) {
+			report("defaults."+msg.key, "defaults: %s", msg.text)
+		}
+		if _, ok := defaults["extends"]; ok {
+			report("defaults.extends", "defaults: `extends` only applies to workflows")
+		}
+	}
+
+	defined := make(map[string]int)
+	workflows, _ := doc["workflows"].(map[string]any)
+	for _, name := range sortedKeys(workflows) {
+		prefix := "workflows." + name
+		defined[name] = lines[prefix]
+
+		wf, ok := workflows[name].(map[string]any)
+		if !ok {
+			report(prefix, "workflow %q must be a table", name)
+			continue
+		}
+		for _, msg := range checkTable(wf, workflowKeys) {
+			report(prefix+"."+msg.key, "workflow %q: %s", name, msg.text)
+		}
+	}
+
+	routines, _ := doc["routines"].(map[string]any)
+	for _, name := range sortedKeys(routines) {
+		prefix := "routines." + name
+		rt, ok := routines[name].(map[string]any)
+		if !ok {
+			report(prefix, "routine %q must be a table", name)
+			continue
+		}
+		for _, msg := range checkTable(rt, routineKeys) {
+			report(prefix+"."+msg.key, "routine %q: %s", name, msg.text)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].line < issues[j].line })
+	return issues, defined, lines
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type keyIssue struct {
+	key  string
+	text string
+}
+
+// checkTable flags unknown keys and values of the wrong type against a schema
+func checkTable(table map[string]any, schema map[string]keyKind) []keyIssue {
+	var issues []keyIssue
+	for _, key := range sortedKeys(table) {
+		kind, ok := schema[key]
+		if !ok {
+			text := fmt.Sprintf("unknown key %q", key)
+			if guess := closestKey(key, schema); guess != "" {
+				text += fmt.Sprintf(" (did you mean %q?)", guess)
+			}
+			issues = append(issues, keyIssue{key, text})
+			continue
+		}
+		if err := checkKind(table[key], kind); err != nil {
+			issues = append(issues, keyIssue{key, fmt.Sprintf("%s: %v", key, err)})
+		}
+	}
+	return issues
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func checkKind(val any, kind keyKind) error {
+	ok := false
+	switch kind {
+	case kindString:
+		_, ok = val.(string)
+	case kindBool:
+		_, ok = val.(bool)
+	case kindInt:
+		_, ok = val.(int64)
+	case kindDuration:
+		s, isString := val.(string)
+		if !isString {
+			break
+		}
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		ok = true
+	case kindIntList:
+		list, isList := val.([]any)
+		ok = isList
+		for _, item := range list {
+			if _, isInt := item.(int64); !isInt {
+				ok = false
+			}
+		}
+	case kindTable:
+		_, ok = val.(map[string]any)
+	case kindTableList:
+		list, isList := val.([]any)
+		ok = isList
+		for _, item := range list {
+			if _, isTable := item.(map[string]any); !isTable {
+				ok = false
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("expected %s, got %v", kind, val)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// checkWorkflowRules catches values that parse but cannot work together
+func checkWorkflowRules(wf map[string]any) []keyIssue {
+	var issues []keyIssue
+	flag := func(key string) bool { b, _ := wf[key].(bool); return b }
+	str := func(key string) string { s, _ := wf[key].(string); return s }
+	iterations, _ := wf["iterations"].(int64)
+
+	if str("script") == "" && !flag("notify_only") {
+		issues = append(issues, keyIssue{"", "missing `script` (or set notify_only = true)"})
+	}
+	if flag("carbonite") && iterations > 0 {
+		issues = append(issues, keyIssue{"iterations", "carbonite runs once as a daemon and cannot be combined with iterations"})
+	}
+	if flag("carbonite") && flag("recurrent") {
+		issues = append(issues, keyIssue{"recurrent", "carbonite runs once as a daemon and cannot be combined with recurrent"})
+	}
+	if flag("recurrent") && iterations > 0 {
+		issues = append(issues, keyIssue{"iterations", "recurrent repeats forever and conflicts with iterations"})
+	}
+	if iterations < 0 {
+		issues = append(issues, keyIssue{"iterations", "iterations must not be negative"})
+	}
+	if retries, _ := wf["retries"].(int64); retries < 0 {
+		issues = append(issues, keyIssue{"retries", "retries must not be negative"})
+	}
+
+	for key, validate := range map[string]func(string) error{
+		"shell":     validateShell,
+		"overlap":   hypnos.ValidateOverlap,
+		"notify_on": hypnos.ValidateNotifyOn,
+	} {
+		if err := validate(str(key)); err != nil {
+			issues = append(issues, keyIssue{key, err.Error()})
+		}
+	}
+	for _, key := range []string{"notify_title", "notify_message"} {
+		if err := hypnos.ValidateNotifyTemplate(key, str(key)); err != nil {
+			issues = append(issues, keyIssue{key, err.Error()})
+		}
+	}
+	// as at launch, only a workflow with vars has its references substituted, and a plain launch
+	// refuses any it leaves
+	if table, _ := wf["vars"].(map[string]any); len(table) > 0 {
+		vars, _ := parseVars(envPairs(table))
+		for _, key := range []string{"script", "probe", "log"} {
+			if _, missing := renderVars(str(key), vars); len(missing) > 0 {
+				issues = append(issues, keyIssue{key, undefinedVars(key, missing)})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].key < issues[j].key })
+	return issues
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// keyLines maps dotted key paths (tables included) to the line where they appear
+func keyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	p := unstable.Parser{}
+	p.Reset(data)
+
+	var table []string
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = table[:0]
+			line := 0
+			for it := expr.Key(); it.Next(); {
+				table = append(table, string(it.Node().Data))
+				line = lineOf(&p, it.Node())
+			}
+			lines[strings.Join(table, ".")] = line
+		case unstable.KeyValue:
+			path := append([]string{}, table...)
+			line := 0
+			for it := expr.Key(); it.Next(); {
+				path = append(path, string(it.Node().Data))
+				if line == 0 {
+					line = lineOf(&p, it.Node())
+				}
+			}
+			for i := len(table) + 1; i <= len(path); i++ {
+				if _, ok := lines[strings.Join(path[:i], ".")]; !ok {
+					lines[strings.Join(path[:i], ".")] = line
+				}
+			}
+		}
+	}
+	return lines
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func lineOf(p *unstable.Parser, n *unstable.Node) int {
+	if n == nil || n.Raw.Length == 0 {
+		return 0
+	}
+	return p.Shape(n.Raw).Start.Line
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// closestKey suggests a schema key within two edits of a misspelt one
+func closestKey(key string, schema map[string]keyKind) string {
+	best, bestDist := "", 3
+	for _, candidate := range sortedKeys(schema) {
+		if d := editDistance(key, candidate); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,131 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCheckConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.TrimPrefix(content, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	a := write("a.toml", `
+[defaults]
+grpup = "system"
+
+[workflows.beat]
+script = "true"
+duration = "soon"
+grpup = "system"
+
+[workflows.daemon]
+script = "serve"
+carbonite = true
+iterations = 3
+
+[workflows.loop]
+script = "true"
+recurrent = true
+iterations = 2
+
+[workflows.base]
+duration = "10m"
+
+[workflows.child]
+extends = "base"
+script = "true"
+
+[workflows.empty]
+duration = "1m"
+`)
+	b := write("b.toml", `
+[workflows.beat]
+script = "true"
+
+[routines.work]
+steps = [{ workflow = "beat" }]
+repaet = 2
+`)
+	c := write("c.toml", `
+[workflows.half
+script = "true"
+`)
+
+	var got []string
+	for _, issue := range checkConfigFiles([]string{a, b, c}, nil) {
+		got = append(got, strings.TrimPrefix(issue.String(), dir+string(filepath.Separator)))
+	}
+	want := []string{
+		`a.toml:2: defaults: unknown key "grpup" (did you mean "group"?)`,
+		`a.toml:6: workflow "beat": duration: invalid duration "soon"`,
+		`a.toml:7: workflow "beat": unknown key "grpup" (did you mean "group"?)`,
+		`a.toml:12: workflow "daemon": carbonite runs once as a daemon and cannot be combined with iterations`,
+		`a.toml:17: workflow "loop": recurrent repeats forever and conflicts with iterations`,
+		// base is a template its child completes, so only a standalone workflow needs a script
+		`a.toml:26: workflow "empty": missing ` + "`script`" + ` (or set notify_only = true)`,
+		`b.toml:1: workflow "beat" is already defined at ` + a + `:4`,
+		`b.toml:6: routine "work": unknown key "repaet" (did you mean "repeat"?)`,
+		`c.toml:1: toml: `,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("issue %d: %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCheckContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.toml")
+	tasks := filepath.Join(dir, "tasks.toml")
+	if err := os.WriteFile(base, []byte("[workflows.base]\nduration = \"10m\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tasks, []byte("[workflows.child]\nextends = \"base\"\nscript = \"true\"\n\n[workflows.orphan]\nextends = \"nope\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// checking one file resolves `extends` against the rest of the config without checking it
+	var got []string
+	for _, issue := range checkConfigFiles([]string{tasks}, []string{base}) {
+		got = append(got, issue.String())
+	}
+	want := []string{tasks + `:6: workflow orphan: extends unknown workflow "nope"`}
+	if !slices.Equal(got, want) {
+		t.Errorf("issues %q, want %q", got, want)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
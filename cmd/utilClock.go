@@ -0,0 +1,102 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/DanielRivasMD/horus"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// initTimeScale reads the hidden --time-scale debug flag, or HYPNOS_TIME_SCALE, and exports it
+// so spawned workers, routines and chained launches run on the same accelerated clock
+func initTimeScale() {
+	const op = "hypnos.timeScale"
+
+	if !GetRootCmd().PersistentFlags().Changed("time-scale") {
+		if env := os.Getenv("HYPNOS_TIME_SCALE"); env != "" {
+			scale, err := strconv.ParseFloat(env, 64)
+			horus.CheckErr(
+				err,
+				horus.WithOp(op),
+				horus.WithCategory("init_error"),
+				horus.WithFormatter(func(he *horus.Herror) string {
+					return horus.OneLineErr(fmt.Sprintf("invalid HYPNOS_TIME_SCALE %q", env))
+				}),
+			)
+			rootFlags.timeScale = scale
+		}
+	}
+
+	if rootFlags.timeScale <= 0 {
+		horus.CheckErr(
+			errors.New(""),
+			horus.WithOp(op),
+			horus.WithMessage(fmt.Sprintf("time scale must be positive, got %g", rootFlags.timeScale)),
+			horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Message) }),
+		)
+	}
+	if rootFlags.timeScale != 1 {
+		horus.CheckErr(
+			os.Setenv("HYPNOS_TIME_SCALE", strconv.FormatFloat(rootFlags.timeScale, 'g', -1, 64)),
+			horus.WithOp(op),
+			horus.WithCategory("init_error"),
+			horus.WithMessage("exporting HYPNOS_TIME_SCALE"),
+		)
+	}
+}
+
+// engineClock is the clock every schedule, retry and timeout runs on
+func engineClock() hypnos.Clock {
+	if rootFlags.timeScale != 1 {
+		return hypnos.NewScaledClock(rootFlags.timeScale)
+	}
+	return hypnos.SystemClock{}
+}
+
+// wallSpan is how much wall time d of engine time takes
+func wallSpan(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / rootFlags.timeScale)
+}
+
+// wallTime is when t, read on the engine clock, comes about on the wall clock. The engine keeps
+// its own time; probe metadata, control replies and the command line all speak wall time
+func wallTime(clock hypnos.Clock, t time.Time) time.Time {
+	if t.IsZero() || rootFlags.timeScale == 1 {
+		return t
+	}
+	return time.Now().Add(wallSpan(t.Sub(clock.Now())))
+}
+
+// engineTime is the reverse of wallTime, for wall times handed to the engine
+func engineTime(clock hypnos.Clock, t time.Time) time.Time {
+	if t.IsZero() || rootFlags.timeScale == 1 {
+		return t
+	}
+	return clock.Now().Add(time.Duration(float64(time.Until(t)) * rootFlags.timeScale))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,304 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// without a control socket, a worker fires on SIGUSR1 keeping its schedule, on SIGUSR2
+// restarting the countdown, and on SIGHUP reloads its next firing from the probe metadata
+const (
+	triggerKeep   = syscall.SIGUSR1
+	triggerReset  = syscall.SIGUSR2
+	controlReload = syscall.SIGHUP
+)
+
+// catchControl catches the control signals, whose default action would terminate the worker.
+// Workers call it first thing, so the window in which a signal could kill them is as short as
+// the process start itself
+func catchControl() chan os.Signal {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, triggerKeep, triggerReset, controlReload)
+	return sigs
+}
+
+// resetControl gives the control signals their default action back, for processes that do not
+// listen for them and for what they exec
+func resetControl() {
+	signal.Reset(triggerKeep, triggerReset, controlReload)
+}
+
+// listenControl feeds the engine from the control socket and from the signals caught by
+// catchControl, the fallback for clients that cannot reach the socket. The returned cleanup,
+// called once the engine stops reading, removes the socket; signals arriving later are still
+// caught, so they neither block nor terminate the worker, and are dropped
+func listenControl(probe string, clock hypnos.Clock, sigs <-chan os.Signal, log func(string, ...any)) (<-chan hypnos.Command, func()) {
+	control := make(chan hypnos.Command)
+	done := make(chan struct{})
+
+	go func() {
+		for sig := range sigs {
+			select {
+			case <-done:
+				continue
+			default:
+			}
+			var cmd hypnos.Command = hypnos.Trigger{Reset: sig == triggerReset}
+			if sig == controlReload {
+				var err error
+				if cmd, err = reloadCommand(probe, clock); err != nil {
+					log("▸ reload ignored: %v", err)
+					continue
+				}
+			}
+			select {
+			case control <- cmd:
+			case <-done:
+			}
+		}
+	}()
+
+	closeSocket, err := serveControl(probe, clock, control)
+	if err != nil {
+		log("▸ control socket unavailable, signals only: %v", err)
+		closeSocket = func() {}
+	}
+	var once sync.Once
+	return control, func() {
+		once.Do(func() {
+			close(done)
+			closeSocket()
+		})
+	}
+}
+
+// reloadCommand reschedules the worker to the next firing stored in its probe metadata
+func reloadCommand(probe string, clock hypnos.Clock) (hypnos.Command, error) {
+	meta, err := readProbeMeta(probe)
+	if err != nil {
+		return nil, err
+	}
+	if meta.NextFire.IsZero() {
+		return nil, errors.New("no next firing in the probe metadata")
+	}
+	return hypnos.Reschedule{At: engineTime(clock, meta.NextFire)}, nil
+}
+
+// persistDeadline keeps the next firing in the probe metadata, where scan, snooze and extend
+// read it. The launcher saves the metadata only once the worker runs, so a missing probe is
+// not worth a log line
+func persistDeadline(probe string, clock hypnos.Clock, log func(string, ...any)) func(time.Time) {
+	return func(next time.Time) {
+		next = wallTime(clock, next)
+		err := updateProbeMeta(probe, func(meta *probeMeta) { meta.NextFire = next })
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log("▸ saving next firing failed: %v", err)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// controllableProbe loads a live probe that listens for control requests. Routine and carbonite
+// workers do not, and signalling them would terminate them
+func controllableProbe(name string) (*probeMeta, error) {
+	meta, alive := liveProbe(name)
+	switch {
+	case meta == nil:
+		return nil, fmt.Errorf("probe %s not found", name)
+	case !alive:
+		return nil, fmt.Errorf("probe %s is not running", name)
+	case meta.Routine != "":
+		return nil, fmt.Errorf("probe %s runs routine %s, which cannot be controlled", name, meta.Routine)
+	case meta.Carbonite:
+		return nil, fmt.Errorf("probe %s is a carbonite daemon, which cannot be controlled", name)
+	}
+	return meta, nil
+}
+
+// signalProbe is the fallback for workers without a socket; a daemon probe without one has ended
+func signalProbe(meta *probeMeta, sig syscall.Signal) error {
+	if meta.Daemon {
+		return fmt.Errorf("probe %s is not running", meta.Probe)
+	}
+	if err := syscall.Kill(meta.PID, sig); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("probe %s is not running", meta.Probe)
+		}
+		return fmt.Errorf("signalling probe %s (PID %d): %w", meta.Probe, meta.PID, err)
+	}
+	return nil
+}
+
+// callProbe is callWorker for a live probe. A worker launched a moment ago may not listen yet, so
+// its socket is waited for briefly before falling back to a signal it might not catch yet
+func callProbe(meta *probeMeta, req controlRequest) (*hypnos.State, error) {
+	for {
+		state, err := callWorker(meta.Probe, req)
+		if !errors.Is(err, errNoSocket) || meta.Routine != "" || meta.Carbonite || time.Since(meta.Quiescence) > controlTimeout {
+			return state, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// probeState asks a live worker for its schedule; nil when it has no control socket
+func probeState(meta *probeMeta) *hypnos.State {
+	if meta.Routine != "" || meta.Carbonite {
+		return nil
+	}
+	state, err := callWorker(meta.Probe, controlRequest{Op: opStatus})
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// triggerProbe asks a live worker to fire now
+func triggerProbe(name string, reset bool) (*probeMeta, error) {
+	meta, err := controllableProbe(name)
+	if err != nil {
+		return nil, err
+	}
+	_, err = callProbe(meta, controlRequest{Op: opTrigger, Reset: reset})
+	if !errors.Is(err, errNoSocket) {
+		return meta, err
+	}
+	sig := triggerKeep
+	if reset {
+		sig = triggerReset
+	}
+	return meta, signalProbe(meta, sig)
+}
+
+// rescheduleProbe moves a live worker's next firing to a time computed from the current one,
+// which is zero when the worker does not report it
+func rescheduleProbe(name string, next func(current time.Time) (time.Time, error)) (*probeMeta, error) {
+	meta, err := controllableProbe(name)
+	if err != nil {
+		return nil, err
+	}
+
+	current := meta.NextFire
+	state, err := callProbe(meta, controlRequest{Op: opStatus})
+	socket := !errors.Is(err, errNoSocket)
+	switch {
+	case socket && err != nil:
+		return nil, err
+	case socket && state.Paused:
+		current = time.Now().Add(state.Remaining)
+	case socket:
+		current = state.Next
+	}
+
+	at, err := next(current)
+	if err != nil {
+		return nil, err
+	}
+	meta.NextFire = at
+	if socket {
+		_, err := callWorker(name, controlRequest{Op: opSnooze, At: at})
+		return meta, err
+	}
+	if err := updateProbeMeta(name, func(m *probeMeta) { m.NextFire = at }); err != nil {
+		return nil, err
+	}
+	return meta, signalProbe(meta, controlReload)
+}
+
+// pauseProbe stops or restarts a live worker's countdown, returning the time left. A frozen
+// process keeps its wall-clock deadline, so a worker without a socket is refused rather than
+// stopped; one found stopped, by hand or by an older hypnos, is continued on resume
+func pauseProbe(name string, resume bool) (*probeMeta, *hypnos.State, error) {
+	meta, err := controllableProbe(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	op := opPause
+	if resume {
+		op = opResume
+	}
+
+	_, err = callProbe(meta, controlRequest{Op: op})
+	switch {
+	case errors.Is(err, errNoSocket) && resume && strings.HasPrefix(processState(meta.PID), "T"):
+		return meta, nil, signalProbe(meta, syscall.SIGCONT)
+	case errors.Is(err, errNoSocket):
+		return nil, nil, fmt.Errorf("probe %s has no control socket, so its countdown cannot be paused or resumed", name)
+	case err != nil:
+		return nil, nil, err
+	}
+	state, err := callWorker(name, controlRequest{Op: opStatus})
+	return meta, state, err
+}
+
+// stopWorker ends a live worker's schedule over its socket, or with SIGTERM without one; a daemon
+// probe without a socket has already ended
+func stopWorker(meta *probeMeta) (viaSocket bool, err error) {
+	if meta.Routine == "" && !meta.Carbonite {
+		_, err := callWorker(meta.Probe, controlRequest{Op: opStop})
+		if !errors.Is(err, errNoSocket) {
+			return true, err
+		}
+	}
+	os.Remove(socketPath(meta.Probe))
+	if meta.Daemon {
+		return false, syscall.ESRCH
+	}
+	return false, syscall.Kill(meta.PID, syscall.SIGTERM)
+}
+
+// exitTimeout bounds the wait for a stopped worker, which may be finishing a script run
+const exitTimeout = 10 * time.Second
+
+// waitStopped waits until a stopped worker lets go of its probe: its process exits or, for a
+// probe in the daemon, its control socket closes as the engine returns. False on timeout
+func waitStopped(meta *probeMeta, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		running := processState(meta.PID) != ""
+		if meta.Daemon {
+			_, err := os.Stat(socketPath(meta.Probe))
+			running = err == nil
+		}
+		if !running {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
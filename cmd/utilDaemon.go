@@ -0,0 +1,324 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func daemonSocketPath() string {
+	return filepath.Join(configDirs.run, "daemon.sock")
+}
+
+func daemonLogPath() string {
+	return filepath.Join(configDirs.log, "daemon.log")
+}
+
+// callDaemon sends one request to the daemon; errNoSocket when no daemon is running
+func callDaemon(req controlRequest) (*controlReply, error) {
+	return call(daemonSocketPath(), req)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// daemon runs probes as goroutines of one process. Every schedule, retry backoff and script
+// timeout waits on a single heap clock, and each probe still answers on its own control socket,
+// so the per-probe commands work unchanged
+type daemon struct {
+	clock   *hypnos.HeapClock
+	started time.Time
+
+	mu      sync.Mutex
+	probes  map[string]*daemonProbe
+	closing bool
+	wg      sync.WaitGroup
+
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+type daemonProbe struct {
+	meta    *probeMeta
+	control chan hypnos.Command
+
+	// the launching shell's environment and directory, which the probe's scripts start from
+	environ []string
+	dir     string
+}
+
+func newDaemon() *daemon {
+	return &daemon{
+		clock:   hypnos.NewHeapClock(rootFlags.timeScale),
+		started: time.Now(),
+		probes:  make(map[string]*daemonProbe),
+		stopped: make(chan struct{}),
+	}
+}
+
+// serve answers the daemon socket until shutdown, requested over the socket or by SIGTERM / SIGINT
+func (d *daemon) serve() error {
+	// probes in the daemon are controlled over their sockets; the scripts it runs keep the
+	// default action of the control signals
+	resetControl()
+
+	cleanup, err := listenSocket(daemonSocketPath(), d.handle)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigs
+		d.shutdown()
+	}()
+
+	d.logf("daemon PID %d listening on %s", os.Getpid(), daemonSocketPath())
+	<-d.stopped
+	d.logf("daemon stopped")
+	return nil
+}
+
+func (d *daemon) logf(format string, a ...any) {
+	fmt.Printf("%s %s\n", time.Now().Format(time.DateTime), fmt.Sprintf(format, a...))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// handle answers the daemon socket
+func (d *daemon) handle(req controlRequest) controlReply {
+	switch req.Op {
+	case opStatus:
+		return controlReply{OK: true, PID: os.Getpid(), Since: d.started, Probes: d.running()}
+	case opLaunch:
+		if err := d.launch(req.Meta, req.Environ, req.Dir); err != nil {
+			return controlReply{Error: err.Error()}
+		}
+		return controlReply{OK: true, PID: os.Getpid()}
+	case opShutdown:
+		go d.shutdown()
+		return controlReply{OK: true, PID: os.Getpid()}
+	}
+	return controlReply{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+}
+
+func (d *daemon) running() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.probes))
+	for name := range d.probes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// launch records the probe as owned by the daemon and starts it. The daemon saves the metadata
+// itself, before the first firing, so a probe that finishes at once is never mistaken for live
+func (d *daemon) launch(meta *probeMeta, environ []string, dir string) error {
+	switch {
+	case meta == nil:
+		return errors.New("launch needs probe metadata")
+	case meta.Carbonite:
+		return fmt.Errorf("probe %s is a carbonite daemon and needs its own process", meta.Probe)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing {
+		return errors.New("daemon is shutting down")
+	}
+
+	meta.PID, meta.Daemon = os.Getpid(), true
+	if err := probes().Save(meta); err != nil {
+		return err
+	}
+
+	p := &daemonProbe{meta: meta, control: make(chan hypnos.Command), environ: environ, dir: dir}
+	d.probes[meta.Probe] = p
+	d.wg.Add(1)
+	go d.run(p)
+	return nil
+}
+
+// run is runHibernateWorker inside the daemon: the same engine, log and chaining, with the
+// shared clock, and the metadata released once the probe ends
+func (d *daemon) run(p *daemonProbe) {
+	defer d.wg.Done()
+	meta := p.meta
+	cfg := metaConfig(meta)
+	cfg.environ, cfg.dir = p.environ, p.dir
+	d.logf("probe %s started", meta.Probe)
+
+	f, err := os.OpenFile(meta.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		d.logf("probe %s: opening log: %v", meta.Probe, err)
+		d.release(p)
+		return
+	}
+	defer f.Close()
+	log := func(format string, a ...any) {
+		fmt.Fprintln(f, fmt.Sprintf(format, a...))
+	}
+
+	cleanup, err := serveControl(meta.Probe, d.clock, p.control)
+	if err != nil {
+		log("▸ control socket unavailable: %v", err)
+		cleanup = func() {}
+	}
+
+	w := newWorker(cfg, log)
+	w.Clock, w.Runner = d.clock, scriptRunner{cfg, d.clock, f}
+	w.Control = p.control
+	w.OnDeadline = d.persistDeadline(p, log)
+	status := w.Run()
+
+	cleanup()
+	d.release(p)
+	if w.Stopped() {
+		d.logf("probe %s stopped", meta.Probe)
+		return
+	}
+	d.logf("probe %s finished (%s)", meta.Probe, status)
+
+	next := cfg.onSuccess
+	if status != hypnos.StatusSuccess {
+		next = cfg.onFailure
+	}
+	if next != "" {
+		chainWorkflow(cfg, next, log)
+	}
+}
+
+// ours reports whether the stored metadata still describes this launch, not a replacement
+func (p *daemonProbe) ours(m *probeMeta) bool {
+	return m.Daemon && m.Quiescence.Equal(p.meta.Quiescence)
+}
+
+func (d *daemon) persistDeadline(p *daemonProbe, log func(string, ...any)) func(time.Time) {
+	return func(next time.Time) {
+		next = wallTime(d.clock, next)
+		err := updateProbeMeta(p.meta.Probe, func(m *probeMeta) {
+			if p.ours(m) {
+				m.NextFire = next
+			}
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log("▸ saving next firing failed: %v", err)
+		}
+	}
+}
+
+// release marks the probe as no longer running, as a worker process does by exiting
+func (d *daemon) release(p *daemonProbe) {
+	d.mu.Lock()
+	if d.probes[p.meta.Probe] == p {
+		delete(d.probes, p.meta.Probe)
+	}
+	d.mu.Unlock()
+
+	err := updateProbeMeta(p.meta.Probe, func(m *probeMeta) {
+		if p.ours(m) {
+			m.PID = 0
+		}
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		d.logf("probe %s: releasing metadata: %v", p.meta.Probe, err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// shutdown stops every probe, lets runs in progress finish and ends serve
+func (d *daemon) shutdown() {
+	d.mu.Lock()
+	d.closing = true
+	probes := make([]*daemonProbe, 0, len(d.probes))
+	for _, p := range d.probes {
+		probes = append(probes, p)
+	}
+	d.mu.Unlock()
+
+	d.logf("shutting down %d probe(s)", len(probes))
+	for _, p := range probes {
+		select {
+		case p.control <- hypnos.Stop{}:
+		case <-time.After(controlTimeout):
+			d.logf("probe %s did not take the stop", p.meta.Probe)
+		}
+	}
+	d.wg.Wait()
+	d.stopOnce.Do(func() { close(d.stopped) })
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// metaConfig rebuilds the worker settings a probe was launched with, the inverse of newProbeMeta
+func metaConfig(meta *probeMeta) configPaths {
+	return configPaths{
+		config:     meta.Workflow,
+		probe:      meta.Probe,
+		script:     meta.Script,
+		log:        strings.TrimSuffix(filepath.Base(meta.LogPath), ".log"),
+		group:      meta.Group,
+		duration:   meta.Duration,
+		recurrent:  meta.Recurrent,
+		iterations: meta.Iterations,
+		notify:     meta.Notify,
+		carbonite:  meta.Carbonite,
+		env:        meta.Env,
+		envFile:    meta.EnvFile,
+		workdir:    meta.Workdir,
+		shell:      meta.Shell,
+		timeout:    meta.Timeout,
+		overlap:    meta.Overlap,
+
+		retries:         meta.Retries,
+		retryBackoff:    meta.RetryBackoff,
+		retryBackoffMax: meta.RetryBackoffMax,
+		successCodes:    meta.SuccessCodes,
+
+		notifyOn:      meta.NotifyOn,
+		notifyTitle:   meta.NotifyTitle,
+		notifyMessage: meta.NotifyMessage,
+		notifyTimeout: meta.NotifyTimeout,
+
+		onSuccess: meta.OnSuccess,
+		onFailure: meta.OnFailure,
+		lineage:   meta.Lineage,
+
+		vars: meta.Vars,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
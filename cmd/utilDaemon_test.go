@@ -0,0 +1,62 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMetaConfigRoundTrip(t *testing.T) {
+	cfg := configPaths{
+		config:          "backup",
+		probe:           "backup-srv",
+		script:          "backup.sh /srv",
+		log:             "backup",
+		group:           "nightly",
+		duration:        24 * time.Hour,
+		recurrent:       true,
+		iterations:      3,
+		env:             []string{"TARGET=/srv"},
+		workdir:         "/srv",
+		shell:           "bash",
+		timeout:         time.Hour,
+		notifyTimeout:   true,
+		overlap:         "skip",
+		retries:         2,
+		retryBackoff:    time.Minute,
+		retryBackoffMax: 10 * time.Minute,
+		successCodes:    []int{0, 3},
+		notifyOn:        "failure",
+		notifyTitle:     "{{.Probe}}",
+		onSuccess:       "report",
+		lineage:         []string{"prepare"},
+		vars:            []string{"name=srv"},
+	}
+
+	// the daemon rebuilds a launch from its metadata alone
+	if got := metaConfig(newProbeMeta(cfg)); !reflect.DeepEqual(got, cfg) {
+		t.Errorf("got  %+v\nwant %+v", got, cfg)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
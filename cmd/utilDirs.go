@@ -0,0 +1,164 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// legacyRoot is where every hypnos file lived before the XDG layout
+func legacyRoot(home string) string {
+	return filepath.Join(home, ".hypnos")
+}
+
+// resolveRoots picks the settings root (config/) and the state root (log/, probe/, history/,
+// hypnos.db): --home, then HYPNOS_HOME, then an existing ~/.hypnos, then the XDG base directories
+func resolveRoots(home, flagHome string) (settings, state string, err error) {
+	root := flagHome
+	if root == "" {
+		root = os.Getenv("HYPNOS_HOME")
+	}
+	if root != "" {
+		if root, err = filepath.Abs(expandPath(root, os.Getenv)); err != nil {
+			return "", "", err
+		}
+		return root, root, nil
+	}
+
+	if info, err := os.Stat(legacyRoot(home)); err == nil && info.IsDir() {
+		return legacyRoot(home), legacyRoot(home), nil
+	}
+
+	settings, state = xdgRoots(home)
+	return settings, state, nil
+}
+
+// xdgRoots follows the base directory spec, which ignores relative paths
+func xdgRoots(home string) (settings, state string) {
+	base := func(env, def string) string {
+		if dir := os.Getenv(env); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(home, def)
+	}
+	return filepath.Join(base("XDG_CONFIG_HOME", ".config"), "hypnos"),
+		filepath.Join(base("XDG_STATE_HOME", filepath.Join(".local", "state")), "hypnos")
+}
+
+func (d *configDir) setRoots(settings, state string) {
+	d.hypnos = settings
+	d.state = state
+	d.config = filepath.Join(settings, "config")
+	d.log = filepath.Join(state, "log")
+	d.probe = filepath.Join(state, "probe")
+	d.history = filepath.Join(state, "history")
+	d.run = filepath.Join(state, "run")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// legacyMove is one entry of the legacy layout and where it belongs now
+type legacyMove struct {
+	from, to string
+}
+
+// planLegacyMigration maps the legacy ~/.hypnos entries onto the target roots. It refuses while
+// probes are recorded, since their metadata points at log files inside the legacy root
+func planLegacyMigration(home string, target configDir) ([]legacyMove, error) {
+	legacy := legacyRoot(home)
+	if info, err := os.Stat(legacy); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("no legacy layout at %s", legacy)
+	}
+	if target.hypnos == legacy || target.state == legacy {
+		return nil, fmt.Errorf("%s is still the active root; unset --home/HYPNOS_HOME or point them elsewhere", legacy)
+	}
+
+	old := &fileStore{dir: filepath.Join(legacy, "probe")}
+	if names, _ := old.Names(); len(names) > 0 {
+		return nil, fmt.Errorf("%d probe(s) recorded in %s; stop them first with hypnos cryostasis --all", len(names), old.dir)
+	}
+	if dbPath := filepath.Join(legacy, "hypnos.db"); !isMissingOrEmpty(dbPath) {
+		db, err := openSQLiteStore(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		names, _ := db.Names()
+		db.db.Close()
+		if len(names) > 0 {
+			return nil, fmt.Errorf("%d probe(s) recorded in %s; stop them first with hypnos cryostasis --all", len(names), dbPath)
+		}
+	}
+
+	var moves []legacyMove
+	add := func(name, root string) {
+		if _, err := os.Stat(filepath.Join(legacy, name)); err == nil {
+			moves = append(moves, legacyMove{filepath.Join(legacy, name), filepath.Join(root, name)})
+		}
+	}
+	add("config", target.hypnos)
+	add(settingsFileName, target.hypnos)
+	for _, name := range []string{"log", "probe", "history", "hypnos.db", "hypnos.db-wal", "hypnos.db-shm"} {
+		add(name, target.state)
+	}
+
+	for _, m := range moves {
+		if !isMissingOrEmpty(m.to) {
+			return nil, fmt.Errorf("%s already exists; move or remove it first", m.to)
+		}
+	}
+	return moves, nil
+}
+
+// applyLegacyMigration moves every planned entry and drops the legacy root once it is empty
+func applyLegacyMigration(home string, moves []legacyMove) error {
+	for _, m := range moves {
+		if err := os.MkdirAll(filepath.Dir(m.to), 0o755); err != nil {
+			return err
+		}
+		os.Remove(m.to) // an empty directory left by prime
+		if err := os.Rename(m.from, m.to); err != nil {
+			if errors.Is(err, syscall.EXDEV) {
+				return fmt.Errorf("%s and %s are on different filesystems; move it by hand", m.from, m.to)
+			}
+			return err
+		}
+	}
+	os.Remove(legacyRoot(home))
+	return nil
+}
+
+func isMissingOrEmpty(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if !info.IsDir() {
+		return false
+	}
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
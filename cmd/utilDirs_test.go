@@ -0,0 +1,155 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestResolveRoots(t *testing.T) {
+	home := t.TempDir()
+	legacy := filepath.Join(home, ".hypnos")
+	xdgConfig, xdgState := filepath.Join(home, "xdg-config"), filepath.Join(home, "xdg-state")
+
+	tests := []struct {
+		name            string
+		flag, env       string
+		legacy          bool
+		config, state   string
+		settings, store string
+	}{
+		{name: "flag over everything", flag: "/srv/flag", env: "/srv/env", legacy: true, config: xdgConfig,
+			settings: "/srv/flag", store: "/srv/flag"},
+		{name: "HYPNOS_HOME over the legacy root", env: "/srv/env", legacy: true,
+			settings: "/srv/env", store: "/srv/env"},
+		{name: "home expanded", env: "~/profile",
+			settings: filepath.Join(home, "profile"), store: filepath.Join(home, "profile")},
+		{name: "legacy root kept while it exists", legacy: true, config: xdgConfig, state: xdgState,
+			settings: legacy, store: legacy},
+		{name: "XDG base directories", config: xdgConfig, state: xdgState,
+			settings: filepath.Join(xdgConfig, "hypnos"), store: filepath.Join(xdgState, "hypnos")},
+		{name: "relative XDG paths ignored", config: "rel/config", state: "rel/state",
+			settings: filepath.Join(home, ".config", "hypnos"), store: filepath.Join(home, ".local", "state", "hypnos")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("HYPNOS_HOME", tt.env)
+			t.Setenv("XDG_CONFIG_HOME", tt.config)
+			t.Setenv("XDG_STATE_HOME", tt.state)
+			os.RemoveAll(legacy)
+			if tt.legacy {
+				if err := os.Mkdir(legacy, 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			settings, state, err := resolveRoots(home, tt.flag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settings != tt.settings || state != tt.store {
+				t.Errorf("roots %s, %s; want %s, %s", settings, state, tt.settings, tt.store)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestLegacyMigration(t *testing.T) {
+	home := t.TempDir()
+	legacy := filepath.Join(home, ".hypnos")
+	var target configDir
+	target.setRoots(filepath.Join(home, "config", "hypnos"), filepath.Join(home, "state", "hypnos"))
+
+	if _, err := planLegacyMigration(home, target); err == nil || !strings.Contains(err.Error(), "no legacy layout") {
+		t.Errorf("without a legacy root: %v", err)
+	}
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(legacy, "config", "tasks.toml"), "[workflows.beat]\n")
+	write(filepath.Join(legacy, settingsFileName), "shell = \"bash\"\n")
+	write(filepath.Join(legacy, "log", "beat.log"), "pulse\n")
+	write(filepath.Join(legacy, "history", "beat.jsonl"), "{}\n")
+	write(filepath.Join(legacy, "probe", "beat.json"), `{"probe": "beat"}`)
+
+	var active configDir
+	active.setRoots(legacy, legacy)
+	if _, err := planLegacyMigration(home, active); err == nil || !strings.Contains(err.Error(), "still the active root") {
+		t.Errorf("onto itself: %v", err)
+	}
+
+	// metadata points into the legacy root, so recorded probes block the move
+	if _, err := planLegacyMigration(home, target); err == nil || !strings.Contains(err.Error(), "1 probe(s) recorded") {
+		t.Errorf("with a recorded probe: %v", err)
+	}
+	os.Remove(filepath.Join(legacy, "probe", "beat.json"))
+
+	write(filepath.Join(target.log, "other.log"), "")
+	if _, err := planLegacyMigration(home, target); err == nil || !strings.Contains(err.Error(), target.log+" already exists") {
+		t.Errorf("over existing logs: %v", err)
+	}
+	os.RemoveAll(target.log)
+
+	// empty directories, as prime leaves them, are replaced
+	if err := os.MkdirAll(target.config, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	moves, err := planLegacyMigration(home, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyLegacyMigration(home, moves); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(target.config, "tasks.toml"),
+		filepath.Join(target.hypnos, settingsFileName),
+		filepath.Join(target.log, "beat.log"),
+		filepath.Join(target.history, "beat.jsonl"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("not migrated: %v", err)
+		}
+	}
+	if _, err := os.Stat(target.probe); err != nil {
+		t.Errorf("probe dir not migrated: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy root left behind: %v", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,190 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/ttacon/chalk"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// how many upcoming firings a dry run lists
+const dryRunFires = 5
+
+// previewLaunch prints what launching cfg would do: the resolved settings, the worker command
+// line and the upcoming firings. Nothing is spawned, signalled or written
+func previewLaunch(cfg configPaths, policy string) {
+	meta := newProbeMeta(cfg)
+	exe, _ := os.Executable()
+
+	header := meta.Probe
+	if cfg.config != "" {
+		header += fmt.Sprintf(" (workflow %s)", cfg.config)
+	}
+	fmt.Printf("%s %s\n", chalk.Cyan.Color("probe"), header)
+
+	if live, ok := liveProbe(meta.Probe); ok {
+		var note string
+		switch policy {
+		case collideReplace:
+			note = fmt.Sprintf("would stop the running probe (PID %d) first", live.PID)
+		case collideUnique:
+			note = "already running; a suffixed name (-2, -3, …) would be picked"
+		default:
+			note = fmt.Sprintf("already running (PID %d); the launch would be refused", live.PID)
+		}
+		fmt.Printf("  %s %s\n", chalk.Yellow.Color("note:"), note)
+	}
+
+	fmt.Println("  settings:")
+	for _, line := range describeConfig(cfg) {
+		fmt.Printf("    %s\n", line)
+	}
+	fmt.Printf("    log = %q\n", meta.LogPath)
+
+	fmt.Println("  worker:")
+	if reply, err := callDaemon(controlRequest{Op: opStatus}); err == nil && !cfg.carbonite {
+		fmt.Printf("    inside daemon PID %d\n", reply.PID)
+	} else {
+		fmt.Printf("    %s\n", shellJoin(append([]string{exe}, workerArgs(meta)...)))
+		if len(meta.Env) > 0 {
+			fmt.Printf("    with %d env entries in %s\n", len(meta.Env), workerEnvVar)
+		}
+	}
+
+	fmt.Println("  fires:")
+	if cfg.carbonite {
+		fmt.Println("    never: runs the script as a daemon right away")
+		return
+	}
+	// fire times are wall times: under a time scale the schedule plays out scale times faster
+	every := wallSpan(cfg.duration)
+	sched := hypnos.NewScheduler(hypnos.SystemClock{}, every, cfg.recurrent, cfg.iterations)
+	now := time.Now()
+	times := sched.Upcoming(dryRunFires)
+	for _, at := range times {
+		fmt.Printf("    %s (in %s)\n", displayTime(at).Format("2006-01-02 15:04:05"), at.Sub(now).Round(time.Millisecond))
+	}
+	switch {
+	case cfg.iterations > len(times):
+		fmt.Printf("    … %d more, every %s\n", cfg.iterations-len(times), every)
+	case cfg.iterations == 0 && cfg.recurrent:
+		fmt.Printf("    … every %s until stopped\n", every)
+	}
+	if rootFlags.timeScale != 1 {
+		fmt.Printf("  %s %g: every %s of schedule takes %s of wall time\n",
+			chalk.Yellow.Color("time scale"), rootFlags.timeScale, cfg.duration, every)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// previewStasis prints what cryostasis would do to a probe without doing it
+func previewStasis(meta *probeMeta) {
+	if processState(meta.PID) != "" {
+		if probeState(meta) != nil {
+			fmt.Printf("would stop PID %d for %q over %s\n", meta.PID, meta.Probe, socketPath(meta.Probe))
+		} else {
+			fmt.Printf("would send SIGTERM to PID %d for %q\n", meta.PID, meta.Probe)
+		}
+	} else {
+		fmt.Printf("process %d for %q not running, nothing to signal\n", meta.PID, meta.Probe)
+	}
+	fmt.Printf("would remove metadata %s\n", probes().Location(meta.Probe))
+	fmt.Printf("would remove log %s\n", meta.LogPath)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// describeConfig lists every workflow key cfg sets, as TOML-like lines
+func describeConfig(cfg configPaths) []string {
+	var lines []string
+	for _, f := range workflowSchema {
+		if f.field == nil || f.key == "log" {
+			continue
+		}
+		var val string
+		switch v := f.field(&cfg).(type) {
+		case *string:
+			if *v != "" {
+				val = fmt.Sprintf("%q", *v)
+			}
+		case *bool:
+			if *v {
+				val = "true"
+			}
+		case *int:
+			if *v != 0 {
+				val = fmt.Sprint(*v)
+			}
+		case *[]int:
+			if len(*v) > 0 {
+				val = strings.ReplaceAll(fmt.Sprint(*v), " ", ", ")
+			}
+		case *time.Duration:
+			if *v != 0 {
+				val = fmt.Sprintf("%q", v.String())
+			}
+		}
+		if val != "" {
+			lines = append(lines, f.key+" = "+val)
+		}
+	}
+	for _, table := range []struct {
+		key   string
+		pairs []string
+	}{{"env", cfg.env}, {"vars", cfg.vars}} {
+		if len(table.pairs) == 0 {
+			continue
+		}
+		entries := make([]string, 0, len(table.pairs))
+		for _, kv := range table.pairs {
+			k, v, _ := strings.Cut(kv, "=")
+			entries = append(entries, fmt.Sprintf("%s = %q", k, v))
+		}
+		lines = append(lines, fmt.Sprintf("%s = { %s }", table.key, strings.Join(entries, ", ")))
+	}
+	return lines
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellJoin renders argv so it can be pasted into a POSIX shell
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if shellSafe.MatchString(arg) {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"hypnos", "--duration", "1h0m0s"}, "hypnos --duration 1h0m0s"},
+		{[]string{"--script", "echo hi; exit 1"}, "--script 'echo hi; exit 1'"},
+		{[]string{"--script", "echo 'quoted'"}, `--script 'echo '"'"'quoted'"'"''`},
+		{[]string{"--script", ""}, "--script ''"},
+		{[]string{"--notify-title", "{{.Probe}}"}, "--notify-title '{{.Probe}}'"},
+	}
+
+	for _, tt := range tests {
+		if got := shellJoin(tt.argv); got != tt.want {
+			t.Errorf("shellJoin(%q) = %s, want %s", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeConfig(t *testing.T) {
+	cfg := configPaths{
+		script:       "backup.sh",
+		group:        "nightly",
+		duration:     24 * time.Hour,
+		recurrent:    true,
+		successCodes: []int{0, 3},
+		env:          []string{"TARGET=/srv"},
+		vars:         []string{"name=srv"},
+	}
+	want := []string{
+		`script = "backup.sh"`,
+		`group = "nightly"`,
+		`duration = "24h0m0s"`,
+		"recurrent = true",
+		"success_codes = [0, 3]",
+		`env = { TARGET = "/srv" }`,
+		`vars = { name = "srv" }`,
+	}
+	if got := describeConfig(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
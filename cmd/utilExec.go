@@ -0,0 +1,456 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// shells accepted by the `shell` workflow key; "exec" runs the script as argv without a shell
+var validShells = []string{"sh", "bash", "zsh", "exec"}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func validateShell(shell string) error {
+	if shell == "" {
+		return nil
+	}
+	for _, s := range validShells {
+		if s == shell {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown shell %q (expected one of %s)", shell, strings.Join(validShells, ", "))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// envPairs flattens an env table into sorted KEY=VALUE entries
+func envPairs(table map[string]any) []string {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, table[k]))
+	}
+	return pairs
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// scriptEnv builds the environment for a script: inherited, then env_file, then env entries,
+// expanding $VAR references against the environment built so far. The inherited environment is
+// the launching shell's when the daemon runs the probe
+func scriptEnv(cfg configPaths) ([]string, error) {
+	vars := make(map[string]string)
+	var order []string
+	set := func(k, v string) {
+		if _, ok := vars[k]; !ok {
+			order = append(order, k)
+		}
+		vars[k] = v
+	}
+	lookup := func(k string) string { return vars[k] }
+
+	inherited := cfg.environ
+	if inherited == nil {
+		inherited = os.Environ()
+	}
+	for _, kv := range inherited {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			set(k, v)
+		}
+	}
+
+	if cfg.envFile != "" {
+		pairs, err := readEnvFile(cfg.inDir(expandPath(cfg.envFile, lookup)))
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range pairs {
+			k, v, _ := strings.Cut(kv, "=")
+			set(k, os.Expand(v, lookup))
+		}
+	}
+
+	for _, kv := range cfg.env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid env entry %q (expected KEY=VALUE)", kv)
+		}
+		set(k, os.Expand(v, lookup))
+	}
+
+	env := make([]string, 0, len(order))
+	for _, k := range order {
+		env = append(env, k+"="+vars[k])
+	}
+	return env, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readEnvFile parses KEY=VALUE lines, skipping blanks and comments and tolerating `export` and quotes
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening env file: %w", err)
+	}
+	defer f.Close()
+
+	var pairs []string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	return pairs, sc.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// expandPath resolves a leading ~ and $VAR references
+func expandPath(path string, lookup func(string) string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = "$HOME" + path[1:]
+	}
+	return os.Expand(path, lookup)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inDir resolves a relative path against the launching shell's directory, when the probe has one
+func (cfg configPaths) inDir(path string) string {
+	if cfg.dir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cfg.dir, path)
+}
+
+// resolveCommand turns a script into argv, environment and working directory for its shell
+func resolveCommand(cfg configPaths) ([]string, []string, string, error) {
+	env, err := scriptEnv(cfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	lookup := func(k string) string {
+		for i := len(env) - 1; i >= 0; i-- {
+			if v, ok := strings.CutPrefix(env[i], k+"="); ok {
+				return v
+			}
+		}
+		return ""
+	}
+
+	dir := cfg.dir
+	if cfg.workdir != "" {
+		dir = cfg.inDir(expandPath(cfg.workdir, lookup))
+	}
+
+	var argv []string
+	switch cfg.shell {
+	case "", "sh":
+		argv = []string{"/bin/sh", "-c", cfg.script}
+	case "bash", "zsh":
+		argv = []string{cfg.shell, "-c", cfg.script}
+	case "exec":
+		argv, err = splitArgs(cfg.script, lookup)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if len(argv) == 0 {
+			return nil, nil, "", fmt.Errorf("empty script for exec shell")
+		}
+	default:
+		return nil, nil, "", validateShell(cfg.shell)
+	}
+
+	return argv, env, dir, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// splitArgs splits a command line on whitespace, honouring quotes and backslashes,
+// and expands ~ and $VAR references outside single quotes
+func splitArgs(s string, lookup func(string) string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inArg   bool
+		quote   byte
+		escaped bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			cur.WriteByte(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case c == '$' && quote != '\'':
+			name, n := varName(s[i+1:])
+			if n == 0 {
+				cur.WriteByte(c)
+			} else {
+				cur.WriteString(lookup(name))
+				i += n
+			}
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '~' && !inArg && (i+1 == len(s) || s[i+1] == '/' || s[i+1] == ' '):
+			cur.WriteString(lookup("HOME"))
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// varName reads a $NAME or ${NAME} reference, returning the name and the bytes consumed
+func varName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		if end := strings.IndexByte(s, '}'); end > 1 {
+			return s[1:end], end + 1
+		}
+		return "", 0
+	}
+	n := 0
+	for n < len(s) && (s[n] == '_' || s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z' || n > 0 && s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	return s[:n], n
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// grace period between SIGTERM and SIGKILL when a script exceeds its timeout
+const killGrace = 10 * time.Second
+
+// how long to keep draining output after the script exits, in case it left children holding the pipes
+const outputGrace = 2 * time.Second
+
+// runScript executes the configured script in its own process group, forwarding output to out,
+// or to the worker's stdout/stderr when out is nil, and killing the whole group once the timeout
+// expires on clock
+func runScript(cfg configPaths, clock hypnos.Clock, out io.Writer) hypnos.Result {
+	res := hypnos.Result{Started: time.Now(), ExitCode: -1}
+	tail := &lastLineWriter{}
+	finish := func(err error) hypnos.Result {
+		res.Finished = time.Now()
+		res.LastLine = tail.line()
+		res.Err = err
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			res.ExitCode = 0
+		case errors.As(err, &exitErr) && !res.TimedOut:
+			res.ExitCode = exitErr.ExitCode()
+		}
+		return res
+	}
+
+	argv, env, dir, err := resolveCommand(cfg)
+	if err != nil {
+		return finish(err)
+	}
+	path, err := lookPathIn(argv[0], env)
+	if err != nil {
+		return finish(err)
+	}
+
+	cmd := exec.Command(path, argv[1:]...)
+	cmd.Env = env
+	cmd.Dir = dir
+	if out != nil {
+		cmd.Stdout = io.MultiWriter(out, tail)
+		cmd.Stderr = cmd.Stdout
+	} else {
+		cmd.Stdout = io.MultiWriter(os.Stdout, tail)
+		cmd.Stderr = io.MultiWriter(os.Stderr, tail)
+	}
+	cmd.WaitDelay = outputGrace
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return finish(err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+
+	var expired <-chan time.Time
+	if cfg.timeout > 0 {
+		expired = clock.After(cfg.timeout)
+	}
+
+	select {
+	case err := <-waitErr:
+		hypnos.CancelAfter(clock, expired)
+		return finish(err)
+	case <-expired:
+		res.TimedOut = true
+		killProcessGroup(cmd.Process.Pid, waitErr)
+		return finish(fmt.Errorf("timed out after %s", cfg.timeout))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// scriptRunner runs a workflow's script for the engine; a nil out keeps the process output
+type scriptRunner struct {
+	cfg   configPaths
+	clock hypnos.Clock
+	out   io.Writer
+}
+
+func (r scriptRunner) Run() hypnos.Result {
+	return runScript(r.cfg, r.clock, r.out)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// killProcessGroup sends SIGTERM to the group, escalating to SIGKILL after killGrace
+// stragglers still in the group once the leader exits are killed outright
+func killProcessGroup(pid int, waitErr <-chan error) {
+	_ = syscall.Kill(-pid, syscall.SIGTERM)
+	select {
+	case <-waitErr:
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+	case <-time.After(killGrace):
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+		<-waitErr
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookPathIn resolves a bare command name against PATH from env
+func lookPathIn(name string, env []string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+	for i := len(env) - 1; i >= 0; i-- {
+		path, ok := strings.CutPrefix(env[i], "PATH=")
+		if !ok {
+			continue
+		}
+		for _, dir := range filepath.SplitList(path) {
+			candidate := filepath.Join(dir, name)
+			if fi, err := os.Stat(candidate); err == nil && !fi.IsDir() && fi.Mode()&0o111 != 0 {
+				return candidate, nil
+			}
+		}
+		break
+	}
+	return exec.LookPath(name)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lastLineWriter keeps the last non-empty line written through it
+type lastLineWriter struct {
+	mu   sync.Mutex
+	last string
+	cur  []byte
+}
+
+func (w *lastLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, b := range p {
+		if b != '\n' {
+			w.cur = append(w.cur, b)
+			continue
+		}
+		if line := strings.TrimSpace(string(w.cur)); line != "" {
+			w.last = line
+		}
+		w.cur = w.cur[:0]
+	}
+	return len(p), nil
+}
+
+func (w *lastLineWriter) line() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := strings.TrimSpace(string(w.cur)); line != "" {
+		return line
+	}
+	return w.last
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -19,6 +19,8 @@ package cmd
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -27,13 +29,106 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/spf13/cobra"
 )
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func spawnProbe(meta *probeMeta) (int, error) {
+// isGroupLaunch reports whether hibernate was asked to spawn a whole group: `--group` with no
+// workflow argument and no manual `--script`
+func isGroupLaunch(cmd *cobra.Command, args []string) bool {
+	return len(args) == 0 && cmd.Flags().Changed("group") && !cmd.Flags().Changed("script")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newProbeMeta captures resolved launcher settings as probe metadata
+func newProbeMeta(cfg configPaths) *probeMeta {
+	return &probeMeta{
+		Probe:      cfg.probe,
+		Workflow:   cfg.config,
+		Group:      cfg.group,
+		Script:     cfg.script,
+		LogPath:    filepath.Join(configDirs.log, cfg.log+".log"),
+		Duration:   cfg.duration,
+		Recurrent:  cfg.recurrent,
+		Iterations: cfg.iterations,
+		Quiescence: time.Now(),
+		NextFire:   nextFire(cfg),
+		Notify:     cfg.notify,
+		Carbonite:  cfg.carbonite,
+		Env:        cfg.env,
+		EnvFile:    cfg.envFile,
+		Workdir:    cfg.workdir,
+		Shell:      cfg.shell,
+		Timeout:    cfg.timeout,
+		Overlap:    cfg.overlap,
+
+		Retries:         cfg.retries,
+		RetryBackoff:    cfg.retryBackoff,
+		RetryBackoffMax: cfg.retryBackoffMax,
+		SuccessCodes:    cfg.successCodes,
+
+		NotifyOn:      cfg.notifyOn,
+		NotifyTitle:   cfg.notifyTitle,
+		NotifyMessage: cfg.notifyMessage,
+		NotifyTimeout: cfg.notifyTimeout,
+
+		OnSuccess: cfg.onSuccess,
+		OnFailure: cfg.onFailure,
+		Lineage:   cfg.lineage,
+
+		Vars: cfg.vars,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// claimAndSpawn claims the probe name of cfg and starts it, holding lockLaunches throughout
+func claimAndSpawn(cfg *configPaths, policy string) (*probeMeta, error) {
+	unlock, err := lockLaunches()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := claimProbe(cfg, policy); err != nil {
+		return nil, err
+	}
+	meta := newProbeMeta(*cfg)
+	return meta, spawnProbe(meta)
+}
+
+// spawnProbe starts a probe and records its metadata: inside the daemon when one is running,
+// which saves the metadata itself, otherwise as a forked worker process
+func spawnProbe(meta *probeMeta) error {
+	if !meta.Carbonite {
+		dir, _ := os.Getwd()
+		reply, err := callDaemon(controlRequest{Op: opLaunch, Meta: meta, Environ: os.Environ(), Dir: dir})
+		if err == nil {
+			meta.PID, meta.Daemon = reply.PID, true
+			return nil
+		}
+		if !errors.Is(err, errNoSocket) {
+			return fmt.Errorf("daemon: %w", err)
+		}
+	}
+
 	exe, _ := os.Executable()
+	pid, err := startWorker(exe, workerArgs(meta), workerEnv(meta), meta.LogPath)
+	if err != nil {
+		return err
+	}
+	meta.PID = pid
+	saveProbeMeta(meta)
+	return nil
+}
 
+// workerArgs is the hibernate-worker command line that runs meta. Env entries are left out, since
+// argv is visible to every user; workerEnv hands them over instead
+func workerArgs(meta *probeMeta) []string {
 	args := []string{
 		"hibernate-worker",
 		"--probe", meta.Probe,
@@ -42,6 +137,10 @@ func spawnProbe(meta *probeMeta) (int, error) {
 		"--duration", meta.Duration.String(),
 	}
 
+	if meta.Group != "" {
+		args = append(args, "--group", meta.Group)
+	}
+
 	if meta.Iterations > 0 {
 		args = append(args, "--iterations", strconv.Itoa(meta.Iterations))
 	} else if meta.Recurrent {
@@ -53,13 +152,100 @@ func spawnProbe(meta *probeMeta) (int, error) {
 	if meta.Carbonite {
 		args = append(args, "--carbonite")
 	}
+	for _, kv := range meta.Vars {
+		args = append(args, "--var", kv)
+	}
+	if meta.EnvFile != "" {
+		args = append(args, "--env-file", meta.EnvFile)
+	}
+	if meta.Workdir != "" {
+		args = append(args, "--workdir", meta.Workdir)
+	}
+	if meta.Shell != "" {
+		args = append(args, "--shell", meta.Shell)
+	}
+	if meta.Timeout > 0 {
+		args = append(args, "--timeout", meta.Timeout.String())
+	}
+	if meta.Overlap != "" {
+		args = append(args, "--overlap", meta.Overlap)
+	}
+	if meta.Retries > 0 {
+		args = append(args,
+			"--retries", strconv.Itoa(meta.Retries),
+			"--retry-backoff", meta.RetryBackoff.String(),
+			"--retry-backoff-max", meta.RetryBackoffMax.String(),
+		)
+	}
+	for _, code := range meta.SuccessCodes {
+		args = append(args, "--success-codes", strconv.Itoa(code))
+	}
+	if meta.NotifyOn != "" {
+		args = append(args, "--notify-on", meta.NotifyOn)
+	}
+	if meta.NotifyTitle != "" {
+		args = append(args, "--notify-title", meta.NotifyTitle)
+	}
+	if meta.NotifyMessage != "" {
+		args = append(args, "--notify-message", meta.NotifyMessage)
+	}
+	if meta.NotifyTimeout {
+		args = append(args, "--notify-timeout")
+	}
+	if meta.OnSuccess != "" {
+		args = append(args, "--on-success", meta.OnSuccess)
+	}
+	if meta.OnFailure != "" {
+		args = append(args, "--on-failure", meta.OnFailure)
+	}
+	if len(meta.Lineage) > 0 {
+		args = append(args, "--lineage", strings.Join(meta.Lineage, ","))
+	}
 
-	f, err := os.OpenFile(meta.LogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	return args
+}
+
+// workerEnvVar carries a probe's env entries from the launcher to its worker, as a JSON list
+const workerEnvVar = "HYPNOS_WORKER_ENV"
+
+// workerEnv is the extra environment of the worker that runs meta
+func workerEnv(meta *probeMeta) []string {
+	if len(meta.Env) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(meta.Env)
+	return []string{workerEnvVar + "=" + string(data)}
+}
+
+// takeWorkerEnv reads the env entries handed over by workerEnv and removes them from the
+// environment, so scripts never see them in that form
+func takeWorkerEnv() ([]string, error) {
+	data, ok := os.LookupEnv(workerEnvVar)
+	if !ok {
+		return nil, nil
+	}
+	os.Unsetenv(workerEnvVar)
+	var env []string
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", workerEnvVar, err)
+	}
+	return env, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// startWorker forks a hidden worker command, adding env to its environment, with its output
+// appended to the probe log
+func startWorker(exe string, args, env []string, logPath string) (int, error) {
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return 0, err
 	}
 
 	cmd := exec.Command(exe, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	cmd.Stdout = f
 	cmd.Stderr = f
 	if err := cmd.Start(); err != nil {
@@ -69,54 +255,113 @@ func spawnProbe(meta *probeMeta) (int, error) {
 	return cmd.Process.Pid, nil
 }
 
+// nextFire is when a freshly launched worker first fires; the worker keeps it current afterwards
+func nextFire(cfg configPaths) time.Time {
+	if cfg.carbonite {
+		return time.Time{}
+	}
+	return time.Now().Add(wallSpan(cfg.duration))
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func runDowntime(d time.Duration, onDone func()) {
-	time.AfterFunc(d, onDone)
+// newWorker hands a probe to the engine, running its script in a shell, recording runs in the
+// probe store and notifying through the configured backend
+func newWorker(cfg configPaths, log func(string, ...any)) *hypnos.Worker {
+	clock := engineClock()
+	return &hypnos.Worker{
+		Spec:     workerSpec(cfg),
+		Clock:    clock,
+		Runner:   scriptRunner{cfg, clock, nil},
+		Notifier: hypnos.NotifierFunc(func(title, msg string) error { return notify(title, msg, log) }),
+		Store:    probes(),
+		Log:      log,
+	}
+}
+
+func workerSpec(cfg configPaths) hypnos.Spec {
+	vars, _ := parseVars(cfg.vars)
+	return hypnos.Spec{
+		Probe:           cfg.probe,
+		Group:           cfg.group,
+		Step:            cfg.step,
+		Duration:        cfg.duration,
+		Recurrent:       cfg.recurrent,
+		Iterations:      cfg.iterations,
+		NotifyOnly:      cfg.notify,
+		Overlap:         cfg.overlap,
+		Timeout:         cfg.timeout,
+		Retries:         cfg.retries,
+		RetryBackoff:    cfg.retryBackoff,
+		RetryBackoffMax: cfg.retryBackoffMax,
+		SuccessCodes:    cfg.successCodes,
+		NotifyOn:        cfg.notifyOn,
+		NotifyTitle:     cfg.notifyTitle,
+		NotifyMessage:   cfg.notifyMessage,
+		NotifyTimeout:   cfg.notifyTimeout,
+		Vars:            vars,
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func runAsDaemon(script string, logFile *os.File) error {
-	if err := syscall.Dup2(int(logFile.Fd()), 1); err != nil {
-		return fmt.Errorf("dup2 stdout: %w", err)
+// longest on_success / on_failure chain before a follow-up is refused, so cycles such as
+// work -> break -> work cannot run forever
+const maxChainDepth = 32
+
+// chainWorkflow launches a follow-up workflow through `hypnos hibernate`, extending the lineage
+func chainWorkflow(cfg configPaths, next string, log func(string, ...any)) {
+	lineage := append(append([]string{}, cfg.lineage...), cfg.probe)
+	if len(lineage) > maxChainDepth {
+		log("▸ chain to %q refused: depth limit of %d reached (%s)", next, maxChainDepth, strings.Join(lineage, " → "))
+		return
 	}
-	if err := syscall.Dup2(int(logFile.Fd()), 2); err != nil {
-		return fmt.Errorf("dup2 stderr: %w", err)
+
+	exe, err := os.Executable()
+	if err != nil {
+		log("▸ chain to %q failed: %v", next, err)
+		return
 	}
-	_ = logFile.Close()
 
-	argv := []string{"/bin/sh", "-c", script}
-	env := os.Environ()
-	return syscall.Exec(argv[0], argv, env)
+	log("▸ chaining to workflow %q (depth %d)", next, len(lineage))
+	// the chained probe takes over any earlier instance of its workflow
+	cmd := exec.Command(exe, "hibernate", next, "--replace", "--lineage", strings.Join(lineage, ","))
+	cmd.Env, cmd.Dir = cfg.environ, cfg.dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log("▸ chain to %q failed: %v", next, err)
+	}
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func notify(title, msg string) error {
-	if tnPath, err := exec.LookPath("terminal-notifier"); err == nil {
-		cmd := exec.Command(
-			tnPath,
-			"-title", title,
-			"-message", msg,
-			"-sender", "com.apple.Terminal",
-		)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("terminal-notifier error: %v – %s", err, output)
+func runAsDaemon(cfg configPaths, logFile *os.File) error {
+	argv, env, dir, err := resolveCommand(cfg)
+	if err != nil {
+		return err
+	}
+	path, err := lookPathIn(argv[0], env)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			return fmt.Errorf("chdir %s: %w", dir, err)
 		}
-		return nil
 	}
 
-	if osaPath, err := exec.LookPath("osascript"); err == nil {
-		script := fmt.Sprintf(`display notification %q with title %q`, msg, title)
-		cmd := exec.Command(osaPath, "-e", script)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("osascript error: %v – %s", err, output)
-		}
-		return nil
+	if err := syscall.Dup2(int(logFile.Fd()), 1); err != nil {
+		return fmt.Errorf("dup2 stdout: %w", err)
 	}
+	if err := syscall.Dup2(int(logFile.Fd()), 2); err != nil {
+		return fmt.Errorf("dup2 stderr: %w", err)
+	}
+	_ = logFile.Close()
 
-	return fmt.Errorf("no macOS notifier found: install terminal-notifier or ensure osascript is in PATH")
+	// the daemon script is no worker: it gets the default action of the control signals
+	resetControl()
+	return syscall.Exec(path, argv, env)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
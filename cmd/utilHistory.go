@@ -0,0 +1,146 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runStats aggregates the history of one probe. A run is one firing: retries of a failed attempt
+// belong to the same run, which counts by its final attempt and takes the time of all of them.
+// Retries are also tallied separately
+type runStats struct {
+	probe    string
+	runs     int
+	success  int
+	failure  int
+	timeout  int
+	skipped  int
+	retries  int
+	executed time.Duration
+	last     hypnos.RunRecord
+}
+
+// mean is the average elapsed time, all attempts included, of runs that actually executed
+func (st runStats) mean() time.Duration {
+	if n := st.runs - st.skipped; n > 0 {
+		return st.executed / time.Duration(n)
+	}
+	return 0
+}
+
+// add counts a run by its final attempt
+func (st *runStats) add(rec hypnos.RunRecord) {
+	st.runs++
+	switch rec.Status {
+	case hypnos.StatusSuccess:
+		st.success++
+	case hypnos.StatusFailure:
+		st.failure++
+	case hypnos.StatusTimeout:
+		st.timeout++
+	case hypnos.StatusSkipped:
+		st.skipped++
+	}
+	if rec.Status != hypnos.StatusSkipped {
+		st.executed += rec.Elapsed
+	}
+	if !rec.Started.Before(st.last.Started) {
+		st.last = rec
+	}
+}
+
+// summarizeRuns groups runs by probe, sorted by probe name. Attempts are folded into runs by
+// (probe, iteration): an attempt that follows the previous one of its iteration is a retry
+func summarizeRuns(runs []hypnos.RunRecord) []runStats {
+	runs = append([]hypnos.RunRecord(nil), runs...)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+
+	type key struct {
+		probe     string
+		iteration int
+	}
+	byProbe := make(map[string]*runStats)
+	pending := make(map[key]hypnos.RunRecord)
+	for _, rec := range runs {
+		st, ok := byProbe[rec.Probe]
+		if !ok {
+			st = &runStats{probe: rec.Probe}
+			byProbe[rec.Probe] = st
+		}
+		k := key{rec.Probe, rec.Iteration}
+		if prev, ok := pending[k]; ok {
+			if rec.Attempt > 1 && rec.Attempt == prev.Attempt+1 {
+				st.retries++
+				rec.Elapsed += prev.Elapsed
+			} else {
+				st.add(prev)
+			}
+		}
+		pending[k] = rec
+	}
+	for k, rec := range pending {
+		byProbe[k.probe].add(rec)
+	}
+
+	out := make([]runStats, 0, len(byProbe))
+	for _, st := range byProbe {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].probe < out[j].probe })
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseSince reads a history cut-off: a span back from now (90m, 36h, 7d) or a date (2026-01-31)
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := parseSpan(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 7d or 36h, or a date such as 2026-01-31", s)
+}
+
+// parseSpan is time.ParseDuration plus whole days (7d)
+func parseSpan(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid span %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
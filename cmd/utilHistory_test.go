@@ -0,0 +1,70 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSummarizeRunsFoldsRetries(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := func(probe string, minute, iteration, attempt int, status string, elapsed time.Duration) hypnos.RunRecord {
+		return hypnos.RunRecord{
+			Probe: probe, Iteration: iteration, Attempt: attempt, Status: status,
+			Started: at.Add(time.Duration(minute) * time.Minute), Elapsed: elapsed,
+		}
+	}
+	runs := []hypnos.RunRecord{
+		// failed twice, then succeeded: one successful run with two retries
+		rec("backup", 0, 1, 1, hypnos.StatusFailure, time.Second),
+		rec("backup", 1, 1, 2, hypnos.StatusFailure, time.Second),
+		rec("backup", 3, 1, 3, hypnos.StatusSuccess, 4*time.Second),
+		rec("backup", 60, 2, 1, hypnos.StatusTimeout, 6*time.Second),
+		// a relaunched worker counts its iterations from 1 again
+		rec("backup", 90, 1, 1, hypnos.StatusSuccess, 2*time.Second),
+		rec("mail", 5, 1, 0, hypnos.StatusSkipped, 0),
+	}
+
+	got := summarizeRuns(runs)
+	if len(got) != 2 {
+		t.Fatalf("%d probes, want 2", len(got))
+	}
+	backup := got[0]
+	if backup.runs != 3 || backup.success != 2 || backup.failure != 0 || backup.timeout != 1 || backup.retries != 2 {
+		t.Errorf("backup: %d runs, %d ok, %d failed, %d timeouts, %d retries; want 3, 2, 0, 1, 2",
+			backup.runs, backup.success, backup.failure, backup.timeout, backup.retries)
+	}
+	// 1s + 1s + 4s for the retried run, 6s and 2s for the others
+	if want := 14 * time.Second / 3; backup.mean() != want {
+		t.Errorf("mean %s, want %s with retries included", backup.mean(), want)
+	}
+	if !backup.last.Started.Equal(at.Add(90 * time.Minute)) {
+		t.Errorf("last run started %s", backup.last.Started)
+	}
+	if mail := got[1]; mail.runs != 1 || mail.skipped != 1 || mail.mean() != 0 {
+		t.Errorf("mail: %+v", mail)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
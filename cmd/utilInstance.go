@@ -0,0 +1,169 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// collision policies for launching a probe whose name is already taken by a live worker
+const (
+	collideRefuse  = ""
+	collideReplace = "replace"
+	collideUnique  = "unique"
+)
+
+func collisionPolicy(replace, unique bool) string {
+	switch {
+	case replace:
+		return collideReplace
+	case unique:
+		return collideUnique
+	}
+	return collideRefuse
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lockLaunches serialises launchers from the check that a probe name is free until the metadata
+// claiming it is saved, so two of them never take the same name. It is a lock of its own, since
+// the store takes its lock for every read and write in between
+func lockLaunches() (func(), error) {
+	unlock, err := flockFile(filepath.Join(configDirs.run, "launch.lock"), syscall.LOCK_EX)
+	if err != nil {
+		return nil, fmt.Errorf("locking launches: %w", err)
+	}
+	return unlock, nil
+}
+
+// claimProbe settles the probe name a new instance is saved under. Metadata left by a dead
+// worker is simply overwritten; a live one is refused, replaced or sidestepped with a -2, -3, …
+// suffix on both probe and log name depending on policy. Singleton workflows never run twice,
+// whatever the probe name. Callers hold lockLaunches until the claimed metadata is saved
+func claimProbe(cfg *configPaths, policy string) error {
+	if cfg.singleton && cfg.config != "" {
+		for _, meta := range liveProbes() {
+			if meta.Workflow != cfg.config {
+				continue
+			}
+			if policy != collideReplace {
+				return fmt.Errorf("workflow %s is a singleton and already running as probe %s (PID %d); pass --replace to restart it",
+					cfg.config, meta.Probe, meta.PID)
+			}
+			if err := stopProbe(meta); err != nil {
+				return err
+			}
+		}
+	}
+
+	meta, alive := liveProbe(cfg.probe)
+	if !alive {
+		return nil
+	}
+
+	switch policy {
+	case collideReplace:
+		return stopProbe(meta)
+	case collideUnique:
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s-%d", cfg.probe, i)
+			if _, taken := liveProbe(candidate); taken {
+				continue
+			}
+			// an instance sharing the base log would lose it on cryostasis of either probe
+			cfg.log = fmt.Sprintf("%s-%d", cfg.log, i)
+			cfg.probe = candidate
+			return nil
+		}
+	default:
+		return fmt.Errorf("probe %s is already running (PID %d); pass --replace or --unique", meta.Probe, meta.PID)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// liveProbe returns a probe's metadata when its worker process still exists
+func liveProbe(name string) (*probeMeta, bool) {
+	meta, err := readProbeMeta(name)
+	if err != nil || meta.PID <= 0 {
+		return meta, false
+	}
+	return meta, processState(meta.PID) != ""
+}
+
+// processState is the ps state of pid, or "" once it has exited; an exited worker nobody has
+// reaped yet lingers as a zombie (Z) and counts as exited. A PID of 0, left by a probe the daemon
+// has released, is never running: ps would report the kernel on macOS
+func processState(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	out, err := exec.Command("ps", "-o", "state=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	state := strings.TrimSpace(string(out))
+	if strings.HasPrefix(state, "Z") {
+		return ""
+	}
+	return state
+}
+
+func liveProbes() []*probeMeta {
+	var out []*probeMeta
+	for _, name := range listProbeNames() {
+		if meta, alive := liveProbe(name); alive {
+			out = append(out, meta)
+		}
+	}
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stopProbe terminates a replaced instance and drops its metadata, keeping its log. A worker
+// chaining into its own workflow is the launcher's parent and finishes by itself; a probe in the
+// daemon is stopped over its socket, never by signalling the daemon
+func stopProbe(meta *probeMeta) error {
+	if meta.Daemon {
+		if _, err := callWorker(meta.Probe, controlRequest{Op: opStop}); err != nil && !errors.Is(err, errNoSocket) {
+			return fmt.Errorf("stopping probe %s in the daemon: %w", meta.Probe, err)
+		}
+	} else if meta.PID != os.Getppid() {
+		if err := syscall.Kill(meta.PID, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("stopping probe %s (PID %d): %w", meta.Probe, meta.PID, err)
+		}
+	}
+	err := probes().Remove(meta.Probe)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
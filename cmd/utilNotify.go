@@ -0,0 +1,94 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// notification backends selected by the `notifier` setting
+const (
+	notifierAuto      = "auto"
+	notifierTerminal  = "terminal-notifier"
+	notifierOsascript = "osascript"
+	notifierLog       = "log"
+	notifierNone      = "none"
+)
+
+func validateNotifier(s string) error {
+	switch s {
+	case notifierAuto, notifierTerminal, notifierOsascript, notifierLog, notifierNone:
+		return nil
+	}
+	return fmt.Errorf("invalid notifier %q (valid: %s, %s, %s, %s, %s)",
+		s, notifierAuto, notifierTerminal, notifierOsascript, notifierLog, notifierNone)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// notify delivers through the backend chosen by the `notifier` setting; "log" writes the
+// notification to the probe log instead, for hosts without a desktop
+func notify(title, msg string, log func(string, ...any)) error {
+	switch backend := settings.GetString("notifier"); backend {
+	case notifierNone:
+		return nil
+	case notifierLog:
+		log("▸ notification: %s: %s", title, msg)
+		return nil
+	case notifierTerminal:
+		return notifyTerminal(title, msg)
+	case notifierOsascript:
+		return notifyOsascript(title, msg)
+	default:
+		if _, err := exec.LookPath("terminal-notifier"); err == nil {
+			return notifyTerminal(title, msg)
+		}
+		if _, err := exec.LookPath("osascript"); err == nil {
+			return notifyOsascript(title, msg)
+		}
+		return fmt.Errorf("no macOS notifier found: install terminal-notifier or ensure osascript is in PATH")
+	}
+}
+
+func notifyTerminal(title, msg string) error {
+	cmd := exec.Command(
+		"terminal-notifier",
+		"-title", title,
+		"-message", msg,
+		"-sender", "com.apple.Terminal",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("terminal-notifier error: %v – %s", err, output)
+	}
+	return nil
+}
+
+func notifyOsascript(title, msg string) error {
+	script := fmt.Sprintf(`display notification %q with title %q`, msg, title)
+	cmd := exec.Command("osascript", "-e", script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("osascript error: %v – %s", err, output)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -34,9 +34,11 @@ func createSubdirs(d configDir, verbose bool, op string) {
 		label, path string
 	}{
 		{"hypnos root", d.hypnos},
+		{"state root", d.state},
 		{"config", d.config},
 		{"log", d.log},
 		{"probe", d.probe},
+		{"history", d.history},
 	}
 
 	for _, dir := range toCreate {
@@ -57,9 +59,14 @@ func createSubdirs(d configDir, verbose bool, op string) {
 func generateToml() string {
 	lines := []string{
 		"# hypnos workflow configuration",
-		"# Save this file as ~/.hypnos/config/<name>.toml",
+		"# Save this file as <config dir>/<name>.toml (~/.hypnos/config or $XDG_CONFIG_HOME/hypnos/config)",
 		"# Each [workflows.<key>] defines a reusable timer preset",
 		"",
+		"# Optional: keys applied to every workflow in this file unless the workflow sets them",
+		"# [defaults]",
+		"# group = \"work\"",
+		"# notify_on = \"failure\"",
+		"",
 		"[workflows.mail]",
 		"# Shell command to execute when the timer expires",
 		"script = \"open -a 'Mail'\"",
@@ -67,7 +74,7 @@ func generateToml() string {
 		"# Duration to wait before executing the script (supports 5s, 10m, 1h)",
 		"duration = \"5s\"",
 		"",
-		"# Basename for the log file (saved under ~/.hypnos/logs/<log>.log)",
+		"# Basename for the log file (saved under <state dir>/log/<log>.log)",
 		"log = \"mail\"",
 		"",
 		"# Unique name for this probe instance (used for metadata and PID tracking)",
@@ -78,6 +85,58 @@ func generateToml() string {
 		"",
 		"# Optional: number of times to run the timer (ignored if recurrent = true)",
 		"# iterations = 3",
+		"",
+		"# Optional: shell used to run the script: sh (default), bash, zsh or exec (argv, no shell)",
+		"# shell = \"bash\"",
+		"",
+		"# Optional: working directory and environment for the script ($HOME-style variables expand)",
+		"# workdir = \"$HOME/mail\"",
+		"# env_file = \"~/.hypnos/mail.env\"",
+		"# env = { MAIL_PROFILE = \"work\" }",
+		"",
+		"# Optional: kill the script's process group (SIGTERM, then SIGKILL) after this long",
+		"# timeout = \"5m\"",
+		"# notify_timeout = true  # notify when that happens",
+		"",
+		"# Optional: when the timer fires while the previous run is active: skip, queue (default) or parallel",
+		"# overlap = \"skip\"",
+		"",
+		"# Optional: retry a failed script, doubling the wait between attempts up to retry_backoff_max",
+		"# retries = 3",
+		"# retry_backoff = \"30s\"",
+		"# retry_backoff_max = \"5m\"",
+		"",
+		"# Optional: exit codes treated as success (default [0])",
+		"# success_codes = [0, 1]",
+		"",
+		"# Optional: when to notify: always (default), failure, success or change",
+		"# notify_on = \"failure\"",
+		"",
+		"# Optional: notification templates; fields: Probe, Group, Iteration, Status, ExitCode,",
+		"# Attempts, LastLine and Summary (the default message)",
+		"# notify_title = \"{{.Probe}} ({{.Group}})\"",
+		"# notify_message = \"#{{.Iteration}} {{.Status}} exit {{.ExitCode}}: {{.LastLine}}\"",
+		"",
+		"# Optional: template variables for script, probe, log and notifications ({{.target}});",
+		"# override at launch with --var target=/other",
+		"# vars = { target = \"/data\" }",
+		"",
+		"# Optional: refuse to launch while another instance of this workflow runs (--replace restarts it)",
+		"# singleton = true",
+		"",
+		"# Optional: inherit every key of another workflow (from any file), overriding only what is set here",
+		"# extends = \"base\"",
+		"",
+		"# Optional: workflow to launch once this probe finishes, by outcome of the last run",
+		"# on_success = \"break\"",
+		"# on_failure = \"mail\"",
+		"",
+		"# Routines run workflows as ordered steps inside a single probe: hypnos routine start <name>",
+		"# [routines.workday]",
+		"# group = \"work\"",
+		"# repeat = 4",
+		"# steps = [{ workflow = \"mail\", duration = \"55m\" }, { workflow = \"break\", duration = \"5m\" }]",
+		"# long_break = { workflow = \"lunch\", duration = \"30m\", every = 4 }",
 	}
 
 	return strings.Join(lines, "\n") + "\n"
@@ -0,0 +1,208 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// configEntry is one [workflows.<name>] or [routines.<name>] table and the file defining it.
+// raw is the table as written; table is the result after [defaults] and `extends` are applied
+type configEntry struct {
+	name     string
+	file     string
+	raw      map[string]any
+	table    map[string]any
+	parents  []string
+	shadowed []string
+	err      error
+}
+
+// configRegistry merges every config file into one namespace. Files load in lexical order and
+// the first definition of a name wins; later definitions are kept as shadowed for reporting
+type configRegistry struct {
+	files     []string
+	broken    map[string]error
+	defaults  map[string]map[string]any
+	workflows map[string]*configEntry
+	routines  map[string]*configEntry
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func loadRegistry() (*configRegistry, error) {
+	entries, err := os.ReadDir(configDirs.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// os.ReadDir sorts by filename, which is the documented precedence
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".toml") {
+			paths = append(paths, filepath.Join(configDirs.config, e.Name()))
+		}
+	}
+	return loadRegistryFiles(paths), nil
+}
+
+// loadRegistryFiles merges the given files, earlier paths taking precedence
+func loadRegistryFiles(paths []string) *configRegistry {
+	reg := &configRegistry{
+		broken:    make(map[string]error),
+		defaults:  make(map[string]map[string]any),
+		workflows: make(map[string]*configEntry),
+		routines:  make(map[string]*configEntry),
+	}
+
+	for _, path := range paths {
+		reg.files = append(reg.files, path)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			reg.broken[path] = err
+			continue
+		}
+		var doc map[string]any
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			reg.broken[path] = err
+			continue
+		}
+
+		reg.defaults[path], _ = doc["defaults"].(map[string]any)
+		reg.add(reg.workflows, path, doc["workflows"])
+		reg.add(reg.routines, path, doc["routines"])
+	}
+
+	lookup := func(name string) (map[string]any, map[string]any, bool) {
+		e, ok := reg.workflows[name]
+		if !ok {
+			return nil, nil, false
+		}
+		return e.raw, reg.defaults[e.file], true
+	}
+	for _, e := range reg.workflows {
+		if e.table, e.parents, e.err = resolveExtends(e.name, lookup, nil); e.err != nil {
+			e.err = fmt.Errorf("workflow %s: %w", e.name, e.err)
+			e.table = e.raw
+		}
+	}
+
+	return reg
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (r *configRegistry) add(into map[string]*configEntry, path string, raw any) {
+	tables, _ := raw.(map[string]any)
+	for _, name := range sortedKeys(tables) {
+		table, ok := tables[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		if first, exists := into[name]; exists {
+			first.shadowed = append(first.shadowed, path)
+			continue
+		}
+		into[name] = &configEntry{name: name, file: path, raw: table, table: table}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (r *configRegistry) workflow(name string) (*configEntry, error) {
+	if e, ok := r.workflows[name]; ok {
+		return e, e.err
+	}
+	return nil, fmt.Errorf("workflow %s not found", name)
+}
+
+func (r *configRegistry) routine(name string) (*configEntry, error) {
+	if e, ok := r.routines[name]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("routine %s not found", name)
+}
+
+func (r *configRegistry) workflowNames() []string { return sortedKeys(r.workflows) }
+
+func (r *configRegistry) routineNames() []string { return sortedKeys(r.routines) }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// viper exposes the entry to bindFlag and the typed getters. viper lowercases the maps it is
+// given in place, so it gets a copy and the entry keeps its original keys
+func (e *configEntry) viper() *viper.Viper {
+	v := viper.New()
+	_ = v.MergeConfigMap(copyTable(e.table))
+	return v
+}
+
+// env returns the entry's env table as KEY=VALUE pairs, keeping the case viper would lose
+func (e *configEntry) env() []string {
+	table, _ := e.table["env"].(map[string]any)
+	return envPairs(table)
+}
+
+// vars returns the entry's vars table as KEY=VALUE defaults for applyVars
+func (e *configEntry) vars() []string {
+	table, _ := e.table["vars"].(map[string]any)
+	return envPairs(table)
+}
+
+// group reads the group label without resolving the whole entry
+func (e *configEntry) group() string {
+	g, _ := e.table["group"].(string)
+	return g
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func copyTable(table map[string]any) map[string]any {
+	out := make(map[string]any, len(table))
+	for k, v := range table {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyTable(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = copyValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
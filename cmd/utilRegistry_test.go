@@ -0,0 +1,179 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestLoadRegistryPrecedence(t *testing.T) {
+	saved := configDirs
+	t.Cleanup(func() { configDirs = saved })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+	if err := os.MkdirAll(configDirs.config, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"20-tasks.toml": "[workflows.beat]\nscript = \"second\"\n\n[workflows.mail]\nscript = \"mail\"\n",
+		"10-base.toml":  "[workflows.beat]\nscript = \"first\"\n\n[routines.beat]\nsteps = [{ workflow = \"beat\" }]\n",
+		"30-more.toml":  "[workflows.beat]\nscript = \"third\"\n",
+		"15-bad.toml":   "[workflows.mail\n",
+		"notes.txt":     "[workflows.beat]\nscript = \"ignored\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(configDirs.config, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(configDirs.config, name) }
+
+	reg, err := loadRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{path("10-base.toml"), path("15-bad.toml"), path("20-tasks.toml"), path("30-more.toml")}; !slices.Equal(reg.files, want) {
+		t.Errorf("files %v, want %v", reg.files, want)
+	}
+	if _, broken := reg.broken[path("15-bad.toml")]; !broken || len(reg.broken) != 1 {
+		t.Errorf("broken files %v", reg.broken)
+	}
+
+	// the first file in name order defines the workflow; later definitions are only reported
+	beat, err := reg.workflow("beat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beat.file != path("10-base.toml") || beat.table["script"] != "first" {
+		t.Errorf("beat from %s: %v", beat.file, beat.table)
+	}
+	if want := []string{path("20-tasks.toml"), path("30-more.toml")}; !slices.Equal(beat.shadowed, want) {
+		t.Errorf("shadowed %v, want %v", beat.shadowed, want)
+	}
+
+	// a broken file does not hide the others, and routines have names of their own
+	if mail, err := reg.workflow("mail"); err != nil || mail.file != path("20-tasks.toml") {
+		t.Errorf("mail: %+v, %v", mail, err)
+	}
+	if names := reg.workflowNames(); !slices.Equal(names, []string{"beat", "mail"}) {
+		t.Errorf("workflows %v", names)
+	}
+	if routine, err := reg.routine("beat"); err != nil || len(routine.shadowed) != 0 {
+		t.Errorf("routine beat: %+v, %v", routine, err)
+	}
+	if _, err := reg.workflow("nope"); err == nil {
+		t.Error("unknown workflow found")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestRegistryExtendsAndDefaults(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.toml")
+	tasks := filepath.Join(dir, "tasks.toml")
+	for path, content := range map[string]string{
+		base: `
+[defaults]
+group = "system"
+notify_only = true
+
+[workflows.base]
+duration = "10m"
+env = { A = "1", B = "1" }
+
+[workflows.loop]
+extends = "loop"
+`,
+		tasks: `
+[defaults]
+group = "work"
+retries = 2
+
+[workflows.middle]
+extends = "base"
+env = { B = "2" }
+
+[workflows.leaf]
+extends = "middle"
+script = "backup.sh"
+env = { C = "3" }
+
+[workflows.plain]
+script = "true"
+
+[workflows.orphan]
+extends = "nope"
+
+[routines.day]
+steps = [{ workflow = "leaf" }]
+`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reg := loadRegistryFiles([]string{base, tasks})
+
+	leaf, err := reg.workflow("leaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(leaf.parents, []string{"middle", "base"}) {
+		t.Errorf("parents %v", leaf.parents)
+	}
+	// own [defaults] first, then the resolved parent, which brings its own file's defaults, then
+	// the workflow's keys; tables such as env merge key by key
+	want := map[string]any{
+		"group":       "system",
+		"notify_only": true,
+		"retries":     int64(2),
+		"duration":    "10m",
+		"script":      "backup.sh",
+		"env":         map[string]any{"A": "1", "B": "2", "C": "3"},
+	}
+	if !reflect.DeepEqual(leaf.table, want) {
+		t.Errorf("leaf resolved to %v\nwant %v", leaf.table, want)
+	}
+	if _, ok := leaf.raw["group"]; ok || leaf.raw["extends"] != "middle" {
+		t.Errorf("table as written changed: %v", leaf.raw)
+	}
+
+	if plain, _ := reg.workflow("plain"); plain.table["group"] != "work" || plain.table["retries"] != int64(2) {
+		t.Errorf("plain did not take its file's defaults: %v", plain.table)
+	}
+	if day, _ := reg.routine("day"); day.table["group"] != nil {
+		t.Errorf("defaults applied to a routine: %v", day.table)
+	}
+
+	for name, msg := range map[string]string{
+		"loop":   "workflow loop: extends cycle loop → loop",
+		"orphan": `workflow orphan: extends unknown workflow "nope"`,
+	} {
+		if _, err := reg.workflow(name); err == nil || err.Error() != msg {
+			t.Errorf("%s: got %v, want %q", name, err, msg)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
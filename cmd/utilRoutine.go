@@ -0,0 +1,192 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// routineStep is one workflow run inside a routine, waiting duration before it fires
+type routineStep struct {
+	workflow string
+	duration time.Duration
+	cfg      configPaths
+}
+
+// routineSpec is a [routines.<name>] table: steps cycled repeat times (0=until stopped),
+// with the final step swapped for longBreak on every longEvery-th cycle
+type routineSpec struct {
+	name      string
+	group     string
+	repeat    int
+	steps     []routineStep
+	longBreak *routineStep
+	longEvery int
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func readRoutine(reg *configRegistry, name string) (*routineSpec, error) {
+	entry, err := reg.routine(name)
+	if err != nil {
+		return nil, err
+	}
+	rt := entry.viper()
+
+	spec := &routineSpec{
+		name:   name,
+		group:  rt.GetString("group"),
+		repeat: rt.GetInt("repeat"),
+	}
+
+	rawSteps, ok := rt.Get("steps").([]any)
+	if !ok || len(rawSteps) == 0 {
+		return nil, fmt.Errorf("routine %s: `steps` must be a non-empty array of { workflow, duration } tables", name)
+	}
+	for i, raw := range rawSteps {
+		table, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("routine %s: step %d is not a table", name, i+1)
+		}
+		step, err := readRoutineStep(reg, table)
+		if err != nil {
+			return nil, fmt.Errorf("routine %s: step %d: %w", name, i+1, err)
+		}
+		spec.steps = append(spec.steps, step)
+	}
+
+	if rt.IsSet("long_break") {
+		table := rt.GetStringMap("long_break")
+		step, err := readRoutineStep(reg, table)
+		if err != nil {
+			return nil, fmt.Errorf("routine %s: long_break: %w", name, err)
+		}
+		spec.longBreak = &step
+		spec.longEvery = cast.ToInt(table["every"])
+		if spec.longEvery <= 0 {
+			return nil, fmt.Errorf("routine %s: long_break needs `every` > 0", name)
+		}
+	}
+
+	return spec, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readRoutineStep resolves the step's workflow, using the step duration when given
+func readRoutineStep(reg *configRegistry, table map[string]any) (routineStep, error) {
+	workflow := cast.ToString(table["workflow"])
+	if workflow == "" {
+		return routineStep{}, fmt.Errorf("missing `workflow`")
+	}
+
+	cfg, err := readWorkflow(reg, workflow, nil)
+	if err != nil {
+		return routineStep{}, err
+	}
+
+	step := routineStep{workflow: workflow, duration: cfg.duration, cfg: cfg}
+	if raw, ok := table["duration"]; ok {
+		if step.duration, err = time.ParseDuration(cast.ToString(raw)); err != nil {
+			return routineStep{}, fmt.Errorf("invalid duration %q: %w", raw, err)
+		}
+	}
+	return step, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stepsFor returns the steps of a cycle, applying the long-break rule
+func (r *routineSpec) stepsFor(cycle int) []routineStep {
+	if r.longBreak == nil || cycle%r.longEvery != 0 {
+		return r.steps
+	}
+	steps := append([]routineStep{}, r.steps...)
+	steps[len(steps)-1] = *r.longBreak
+	return steps
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func spawnRoutine(meta *probeMeta) (int, error) {
+	exe, _ := os.Executable()
+
+	args := []string{
+		"routine-worker",
+		"--probe", meta.Probe,
+		"--routine", meta.Routine,
+		"--log", strings.TrimSuffix(filepath.Base(meta.LogPath), ".log"),
+	}
+	if meta.Group != "" {
+		args = append(args, "--group", meta.Group)
+	}
+
+	return startWorker(exe, args, nil, meta.LogPath)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runRoutine drives every step in order as a single probe, publishing the current step in its metadata
+func runRoutine(spec *routineSpec, probe, group string, log func(string, ...any)) {
+	// one worker across steps, so notify_on = "change" compares consecutive steps
+	w := newWorker(configPaths{probe: probe}, log)
+
+	for cycle := 1; spec.repeat == 0 || cycle <= spec.repeat; cycle++ {
+		steps := spec.stepsFor(cycle)
+		for i, step := range steps {
+			label := fmt.Sprintf("%s %d/%d", step.workflow, i+1, len(steps))
+			if spec.repeat > 0 {
+				label += fmt.Sprintf(" · cycle %d/%d", cycle, spec.repeat)
+			} else {
+				label += fmt.Sprintf(" · cycle %d", cycle)
+			}
+
+			if err := updateProbeMeta(probe, func(m *probeMeta) {
+				m.Step = label
+				m.Cycle = cycle
+				m.StepStarted = time.Now()
+				m.Duration = step.duration
+			}); err != nil {
+				log("▸ updating metadata failed: %v", err)
+			}
+			log("▸ step %s started for %s", label, step.duration)
+
+			<-w.Clock.After(step.duration)
+
+			cfg := step.cfg
+			cfg.probe = probe
+			cfg.step = step.workflow
+			if group != "" {
+				cfg.group = group
+			}
+			w.Spec, w.Runner = workerSpec(cfg), scriptRunner{cfg, w.Clock, nil}
+			w.Fire(cycle)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,144 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadTestRegistry writes config into a single file and loads it as the registry
+func loadTestRegistry(t *testing.T, config string) *configRegistry {
+	path := filepath.Join(t.TempDir(), "tasks.toml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return loadRegistryFiles([]string{path})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestReadRoutine(t *testing.T) {
+	reg := loadTestRegistry(t, `
+[workflows.focus]
+script = "echo focus"
+duration = "25m"
+
+[workflows.pause]
+notify_only = true
+duration = "5m"
+
+[routines.work]
+group = "office"
+repeat = 4
+steps = [
+	{ workflow = "focus" },
+	{ workflow = "pause", duration = "3m" },
+]
+long_break = { workflow = "pause", duration = "15m", every = 2 }
+
+[routines.empty]
+steps = []
+
+[routines.lost]
+steps = [{ workflow = "nope" }]
+
+[routines.nameless]
+steps = [{ duration = "5m" }]
+
+[routines.bad_step]
+steps = [{ workflow = "focus", duration = "soon" }]
+
+[routines.never]
+steps = [{ workflow = "focus" }]
+long_break = { workflow = "pause", every = 0 }
+`)
+
+	spec, err := readRoutine(reg, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.group != "office" || spec.repeat != 4 || len(spec.steps) != 2 {
+		t.Fatalf("routine %+v", spec)
+	}
+	// a step runs its workflow, for the step's own duration when it sets one
+	if step := spec.steps[0]; step.workflow != "focus" || step.duration != 25*time.Minute || step.cfg.script != "echo focus" {
+		t.Errorf("first step %+v", step)
+	}
+	if step := spec.steps[1]; step.workflow != "pause" || step.duration != 3*time.Minute || !step.cfg.notify {
+		t.Errorf("second step %+v", step)
+	}
+	if spec.longBreak == nil || spec.longBreak.duration != 15*time.Minute || spec.longEvery != 2 {
+		t.Errorf("long break %+v every %d", spec.longBreak, spec.longEvery)
+	}
+
+	for name, want := range map[string]string{
+		"empty":    "`steps` must be a non-empty array",
+		"lost":     "step 1: workflow nope not found",
+		"nameless": "step 1: missing `workflow`",
+		"bad_step": `step 1: invalid duration "soon"`,
+		"never":    "long_break needs `every` > 0",
+		"missing":  "routine missing not found",
+	} {
+		if _, err := readRoutine(reg, name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestRoutineLongBreak(t *testing.T) {
+	step := func(workflow string) routineStep { return routineStep{workflow: workflow} }
+	long := step("long")
+	spec := &routineSpec{steps: []routineStep{step("focus"), step("pause")}, longBreak: &long, longEvery: 3}
+
+	workflows := func(steps []routineStep) []string {
+		var out []string
+		for _, s := range steps {
+			out = append(out, s.workflow)
+		}
+		return out
+	}
+	// every third cycle swaps the final step for the long break, leaving the routine as defined
+	for cycle, want := range map[int][]string{
+		1: {"focus", "pause"},
+		2: {"focus", "pause"},
+		3: {"focus", "long"},
+		6: {"focus", "long"},
+	} {
+		if got := workflows(spec.stepsFor(cycle)); !slices.Equal(got, want) {
+			t.Errorf("cycle %d: %v, want %v", cycle, got, want)
+		}
+	}
+	if got := workflows(spec.steps); !slices.Equal(got, []string{"focus", "pause"}) {
+		t.Errorf("steps changed to %v", got)
+	}
+
+	spec.longBreak = nil
+	if got := workflows(spec.stepsFor(3)); !slices.Equal(got, []string{"focus", "pause"}) {
+		t.Errorf("without a long break: %v", got)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
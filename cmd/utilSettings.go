@@ -0,0 +1,242 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DanielRivasMD/horus"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// settingsFileName sits in the settings root, next to config/, so workflow loading never sees it
+const settingsFileName = "hypnos.toml"
+
+// setting is one key of the global settings file with its built-in default
+type setting struct {
+	def      string
+	doc      string
+	validate func(string) error
+}
+
+// settingKeys is the schema of hypnos.toml. Workflow-level keys resolve as
+// flag > workflow > hypnos.toml > built-in
+var settingKeys = map[string]setting{
+	"notifier":      {notifierAuto, "notification backend: auto, terminal-notifier, osascript, log or none", validateNotifier},
+	"log_retention": {"", "remove logs of finished probes older than this (e.g. 30d); empty keeps them", validateRetention},
+	"default_group": {"", "group label for workflows and probes that set none", nil},
+	"shell":         {"", "shell for workflows that set none: sh, bash, zsh or exec", validateShell},
+	"timezone":      {"", "IANA zone for times shown by scan and history; empty uses the local zone", validateTimezone},
+	"store":         {storeFile, "probe store backend: file or sqlite (HYPNOS_STORE overrides)", validateStore},
+}
+
+var settings = viper.New()
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func settingsPath() string {
+	return filepath.Join(configDirs.hypnos, settingsFileName)
+}
+
+// initSettings loads hypnos.toml over the built-in defaults; a missing file is not an error
+func initSettings() {
+	const op = "hypnos.settings"
+
+	settings = viper.New()
+	for key, s := range settingKeys {
+		settings.SetDefault(key, s.def)
+	}
+
+	path := settingsPath()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	settings.SetConfigFile(path)
+	horus.CheckErr(
+		settings.ReadInConfig(),
+		horus.WithOp(op),
+		horus.WithCategory("config_error"),
+		horus.WithFormatter(func(he *horus.Herror) string {
+			return horus.OneLineErr(fmt.Sprintf("%s: %v", path, he.Err))
+		}),
+	)
+
+	for _, key := range sortedKeys(settingKeys) {
+		if validate := settingKeys[key].validate; validate != nil {
+			horus.CheckErr(
+				validate(settings.GetString(key)),
+				horus.WithOp(op),
+				horus.WithCategory("config_error"),
+				horus.WithFormatter(func(he *horus.Herror) string {
+					return horus.OneLineErr(fmt.Sprintf("%s: %s: %v", path, key, he.Err))
+				}),
+			)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// workflowDefaults exposes the global keys that stand in for unset workflow keys, for bindFlag
+func workflowDefaults() *viper.Viper {
+	v := viper.New()
+	if g := settings.GetString("default_group"); g != "" {
+		v.Set("group", g)
+	}
+	if sh := settings.GetString("shell"); sh != "" {
+		v.Set("shell", sh)
+	}
+	return v
+}
+
+// applyWorkflowDefaults fills settings a workflow left empty, for callers outside cobra flags
+func applyWorkflowDefaults(cfg *configPaths) {
+	if cfg.group == "" {
+		cfg.group = settings.GetString("default_group")
+	}
+	if cfg.shell == "" {
+		cfg.shell = settings.GetString("shell")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// displayTime converts a timestamp to the configured timezone
+func displayTime(t time.Time) time.Time {
+	tz := settings.GetString("timezone")
+	if tz == "" {
+		return t
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// pruneLogs removes log files older than log_retention that no stored probe still writes to
+func pruneLogs() error {
+	retention := settings.GetString("log_retention")
+	if retention == "" {
+		return nil
+	}
+	keep, err := parseSpan(retention)
+	if err != nil {
+		return err
+	}
+
+	inUse := make(map[string]bool)
+	for _, name := range listProbeNames() {
+		if meta, err := readProbeMeta(name); err == nil {
+			inUse[meta.LogPath] = true
+		}
+	}
+
+	entries, err := os.ReadDir(configDirs.log)
+	if err != nil {
+		return nil
+	}
+	cutoff := time.Now().Add(-keep)
+	for _, e := range entries {
+		path := filepath.Join(configDirs.log, e.Name())
+		info, err := e.Info()
+		if err != nil || e.IsDir() || inUse[path] || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// writeSetting validates and stores one key in hypnos.toml, keeping the other keys as they are
+func writeSetting(key, value string) error {
+	s, ok := settingKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q (valid: %v)", key, sortedKeys(settingKeys))
+	}
+	if s.validate != nil {
+		if err := s.validate(value); err != nil {
+			return err
+		}
+	}
+
+	doc := make(map[string]any)
+	path := settingsPath()
+	if data, err := os.ReadFile(path); err == nil {
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if value == "" {
+		delete(doc, key)
+	} else {
+		doc[key] = value
+	}
+
+	data, err := toml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func validateRetention(s string) error {
+	if s == "" {
+		return nil
+	}
+	if _, err := parseSpan(s); err != nil {
+		return fmt.Errorf("invalid retention %q: use a duration such as 30d or 72h", s)
+	}
+	return nil
+}
+
+func validateTimezone(s string) error {
+	if _, err := time.LoadLocation(s); err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", s, err)
+	}
+	return nil
+}
+
+func validateStore(s string) error {
+	switch s {
+	case "", storeFile, storeSQLite:
+		return nil
+	}
+	return fmt.Errorf("invalid store %q (valid: %s, %s)", s, storeFile, storeSQLite)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
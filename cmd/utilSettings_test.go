@@ -0,0 +1,184 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// useSettings points the settings root at a temp dir and restores the loaded settings afterwards
+func useSettings(t *testing.T) {
+	savedDirs, savedSettings := configDirs, settings
+	t.Cleanup(func() { configDirs, settings = savedDirs, savedSettings })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+	settings = viper.New()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWriteSetting(t *testing.T) {
+	useSettings(t)
+
+	if err := writeSetting("grpup", "x"); err == nil || !strings.Contains(err.Error(), `unknown setting "grpup"`) {
+		t.Errorf("unknown key: %v", err)
+	}
+	if err := writeSetting("timezone", "Mars/Olympus"); err == nil || !strings.Contains(err.Error(), "invalid timezone") {
+		t.Errorf("invalid value: %v", err)
+	}
+	if _, err := os.Stat(settingsPath()); !os.IsNotExist(err) {
+		t.Fatalf("refused values written: %v", err)
+	}
+
+	for _, kv := range [][2]string{{"shell", "bash"}, {"default_group", "work"}, {"shell", "zsh"}} {
+		if err := writeSetting(kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	initSettings()
+	if settings.GetString("shell") != "zsh" || settings.GetString("default_group") != "work" {
+		t.Errorf("loaded shell %q, group %q", settings.GetString("shell"), settings.GetString("default_group"))
+	}
+	// unset keys keep their built-in default
+	if got := settings.GetString("notifier"); got != notifierAuto {
+		t.Errorf("notifier %q, want the built-in %q", got, notifierAuto)
+	}
+
+	// an empty value removes the key and leaves the others
+	if err := writeSetting("shell", ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(settingsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "shell") || !strings.Contains(string(data), "work") {
+		t.Errorf("after unsetting shell:\n%s", data)
+	}
+}
+
+func TestWorkflowSettingPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		workflow string
+		args     []string
+		want     string
+	}{
+		{name: "built-in"},
+		{name: "global over built-in", global: "global", want: "global"},
+		{name: "workflow over global", global: "global", workflow: `group = "workflow"`, want: "workflow"},
+		{name: "flag over workflow", global: "global", workflow: `group = "workflow"`, args: []string{"--group", "flag"}, want: "flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSettings(t)
+			if tt.global != "" {
+				settings.Set("default_group", tt.global)
+			}
+
+			// as the launcher binds them: flags, then the workflow, then hypnos.toml
+			launcher = configPaths{}
+			cmd := HibernateLauncherCmd()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			wf := viper.New()
+			wf.SetConfigType("toml")
+			if err := wf.ReadConfig(strings.NewReader(tt.workflow)); err != nil {
+				t.Fatal(err)
+			}
+			if err := bindWorkflow(cmd, wf, &launcher); err != nil {
+				t.Fatal(err)
+			}
+			bindFlag(cmd, "group", workflowDefaults())
+			if launcher.group != tt.want {
+				t.Errorf("launcher group %q, want %q", launcher.group, tt.want)
+			}
+
+			// and as chains and groups resolve a workflow without flags
+			if len(tt.args) > 0 {
+				return
+			}
+			reg := loadTestRegistry(t, "[workflows.beat]\nscript = \"true\"\n"+tt.workflow+"\n")
+			cfg, err := readWorkflow(reg, "beat", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.group != tt.want {
+				t.Errorf("workflow group %q, want %q", cfg.group, tt.want)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestPruneLogs(t *testing.T) {
+	useSettings(t)
+	resetStore(t)
+	if err := os.MkdirAll(configDirs.log, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	logs := map[string]time.Time{"old.log": old, "fresh.log": time.Now(), "running.log": old}
+	for name, mtime := range logs {
+		path := filepath.Join(configDirs.log, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveProbeMeta(&probeMeta{Probe: "running", LogPath: filepath.Join(configDirs.log, "running.log")})
+
+	remaining := func() map[string]bool {
+		entries, _ := os.ReadDir(configDirs.log)
+		out := make(map[string]bool)
+		for _, e := range entries {
+			out[e.Name()] = true
+		}
+		return out
+	}
+
+	// without a retention every log is kept
+	if err := pruneLogs(); err != nil || len(remaining()) != 3 {
+		t.Fatalf("pruned without a retention: %v, %v", remaining(), err)
+	}
+
+	// past the retention only logs no stored probe writes to go
+	settings.Set("log_retention", "1h")
+	if err := pruneLogs(); err != nil {
+		t.Fatal(err)
+	}
+	if got := remaining(); got["old.log"] || !got["fresh.log"] || !got["running.log"] {
+		t.Errorf("after pruning: %v", got)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,226 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// control socket operations, one JSON request and one JSON reply per connection
+const (
+	opStatus  = "status"
+	opPause   = "pause"
+	opResume  = "resume"
+	opTrigger = "trigger"
+	opSnooze  = "snooze"
+	opStop    = "stop"
+	opReload  = "reload"
+
+	// daemon socket only
+	opLaunch   = "launch"
+	opShutdown = "shutdown"
+)
+
+// controlTimeout bounds a whole exchange, including the wait for the engine to take the command
+const controlTimeout = 2 * time.Second
+
+type controlRequest struct {
+	Op    string     `json:"op"`
+	Reset bool       `json:"reset,omitempty"`
+	At    time.Time  `json:"at,omitzero"`
+	Meta  *probeMeta `json:"meta,omitempty"`
+
+	// a launch carries the caller's environment and directory, which a forked worker would inherit
+	Environ []string `json:"environ,omitempty"`
+	Dir     string   `json:"dir,omitempty"`
+}
+
+type controlReply struct {
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	State  *hypnos.State `json:"state,omitempty"`
+	PID    int           `json:"pid,omitempty"`
+	Since  time.Time     `json:"since,omitzero"`
+	Probes []string      `json:"probes,omitempty"`
+}
+
+// errNoSocket marks a worker without a control socket, left by an older hypnos or by a worker
+// killed before it could clean up; callers fall back to signals
+var errNoSocket = errors.New("no control socket")
+
+func socketPath(probe string) string {
+	return filepath.Join(configDirs.run, probe+".sock")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// serveControl answers requests on the probe's control socket by handing commands to the engine,
+// which runs on clock
+func serveControl(probe string, clock hypnos.Clock, control chan<- hypnos.Command) (func(), error) {
+	return listenSocket(socketPath(probe), func(req controlRequest) controlReply {
+		state, err := handleControl(req, probe, clock, control)
+		if err != nil {
+			return controlReply{Error: err.Error()}
+		}
+		return controlReply{OK: true, State: state}
+	})
+}
+
+// listenSocket serves one JSON request and reply per connection on a Unix socket. The returned
+// cleanup removes the socket unless a newer listener has replaced it meanwhile
+func listenSocket(path string, handle func(controlRequest) controlReply) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// a socket left by a dead process would refuse the bind
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ours, _ := os.Stat(path)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go answer(conn, handle)
+		}
+	}()
+
+	return func() {
+		ln.Close()
+		if now, err := os.Stat(path); err == nil && os.SameFile(ours, now) {
+			os.Remove(path)
+		}
+	}, nil
+}
+
+func answer(conn net.Conn, handle func(controlRequest) controlReply) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+
+	var req controlRequest
+	reply := controlReply{}
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		reply.Error = fmt.Sprintf("malformed request: %v", err)
+	} else {
+		reply = handle(req)
+	}
+	json.NewEncoder(conn).Encode(reply)
+}
+
+// handleControl turns a request into an engine command; a status request also waits for the reply.
+// Requests and replies carry wall time, converted from and to the engine clock
+func handleControl(req controlRequest, probe string, clock hypnos.Clock, control chan<- hypnos.Command) (*hypnos.State, error) {
+	var cmd hypnos.Command
+	states := make(chan hypnos.State, 1)
+	switch req.Op {
+	case opStatus:
+		cmd = hypnos.Status{Reply: states}
+	case opPause:
+		cmd = hypnos.Pause{}
+	case opResume:
+		cmd = hypnos.Resume{}
+	case opTrigger:
+		cmd = hypnos.Trigger{Reset: req.Reset}
+	case opSnooze:
+		if req.At.IsZero() {
+			return nil, errors.New("snooze needs a time")
+		}
+		cmd = hypnos.Reschedule{At: engineTime(clock, req.At)}
+	case opStop:
+		cmd = hypnos.Stop{}
+	case opReload:
+		c, err := reloadCommand(probe, clock)
+		if err != nil {
+			return nil, err
+		}
+		cmd = c
+	default:
+		return nil, fmt.Errorf("unknown operation %q", req.Op)
+	}
+
+	timeout := time.After(controlTimeout)
+	select {
+	case control <- cmd:
+	case <-timeout:
+		return nil, errors.New("worker is busy or finishing")
+	}
+	if req.Op != opStatus {
+		return nil, nil
+	}
+	select {
+	case st := <-states:
+		st.Next, st.Remaining = wallTime(clock, st.Next), wallSpan(st.Remaining)
+		return &st, nil
+	case <-timeout:
+		return nil, errors.New("worker did not report its status")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// callWorker sends one request to a probe's control socket and waits for the acknowledgement
+func callWorker(probe string, req controlRequest) (*hypnos.State, error) {
+	reply, err := call(socketPath(probe), req)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: %w", probe, err)
+	}
+	return reply.State, nil
+}
+
+// call sends one request over a Unix socket; errNoSocket when nothing listens there
+func call(path string, req controlRequest) (*controlReply, error) {
+	conn, err := net.DialTimeout("unix", path, controlTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoSocket, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * controlTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var reply controlReply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("reading reply: %w", err)
+	}
+	if !reply.OK {
+		return nil, errors.New(reply.Error)
+	}
+	return &reply, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
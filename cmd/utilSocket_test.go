@@ -0,0 +1,207 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestListenControlAfterCleanup(t *testing.T) {
+	saved := configDirs
+	t.Cleanup(func() { configDirs = saved })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+
+	control, cleanup := listenControl("beat", hypnos.SystemClock{}, catchControl(), t.Logf)
+	if err := syscall.Kill(os.Getpid(), triggerKeep); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cmd := <-control:
+		if cmd != (hypnos.Trigger{}) {
+			t.Errorf("SIGUSR1 delivered %#v", cmd)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("signal not delivered")
+	}
+
+	// with nobody reading, late signals are dropped instead of blocking or killing the worker
+	cleanup()
+	cleanup()
+	for _, sig := range []syscall.Signal{triggerKeep, triggerReset, controlReload} {
+		if err := syscall.Kill(os.Getpid(), sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(socketPath("beat")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket left behind: %v", err)
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	saved := configDirs
+	t.Cleanup(func() { configDirs = saved })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+
+	control := make(chan hypnos.Command)
+	cleanup, err := serveControl("beat", hypnos.SystemClock{}, control)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a stand-in engine that reports a fixed state and records every other command
+	next := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	got := make(chan hypnos.Command, 8)
+	go func() {
+		for cmd := range control {
+			if st, ok := cmd.(hypnos.Status); ok {
+				st.Reply <- hypnos.State{Fired: 2, Next: next}
+				continue
+			}
+			got <- cmd
+		}
+	}()
+
+	state, err := callWorker("beat", controlRequest{Op: opStatus})
+	if err != nil || state == nil || state.Fired != 2 || !state.Next.Equal(next) {
+		t.Fatalf("status = %+v, %v", state, err)
+	}
+
+	requests := []struct {
+		req  controlRequest
+		want hypnos.Command
+	}{
+		{controlRequest{Op: opTrigger, Reset: true}, hypnos.Trigger{Reset: true}},
+		{controlRequest{Op: opSnooze, At: next}, hypnos.Reschedule{At: next}},
+		{controlRequest{Op: opPause}, hypnos.Pause{}},
+		{controlRequest{Op: opResume}, hypnos.Resume{}},
+		{controlRequest{Op: opStop}, hypnos.Stop{}},
+	}
+	for _, tt := range requests {
+		if _, err := callWorker("beat", tt.req); err != nil {
+			t.Fatalf("%s: %v", tt.req.Op, err)
+		}
+		if cmd := <-got; !reflect.DeepEqual(cmd, tt.want) {
+			t.Errorf("%s delivered %#v, want %#v", tt.req.Op, cmd, tt.want)
+		}
+	}
+
+	for _, req := range []controlRequest{{Op: "bogus"}, {Op: opSnooze}} {
+		if _, err := callWorker("beat", req); err == nil || errors.Is(err, errNoSocket) || !strings.Contains(err.Error(), "probe beat") {
+			t.Errorf("%q: got %v, want a refusal from the worker", req.Op, err)
+		}
+	}
+
+	cleanup()
+	if _, err := os.Stat(socketPath("beat")); !os.IsNotExist(err) {
+		t.Errorf("socket left behind: %v", err)
+	}
+	if _, err := callWorker("beat", controlRequest{Op: opStatus}); !errors.Is(err, errNoSocket) {
+		t.Errorf("closed socket: got %v, want errNoSocket", err)
+	}
+}
+
+func TestControlSocketKeepsReplacement(t *testing.T) {
+	saved := configDirs
+	t.Cleanup(func() { configDirs = saved })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+
+	oldCleanup, err := serveControl("beat", hypnos.SystemClock{}, make(chan hypnos.Command))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCleanup, err := serveControl("beat", hypnos.SystemClock{}, make(chan hypnos.Command))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newCleanup()
+
+	// the replaced worker exits last and must not take the new socket with it
+	oldCleanup()
+	if _, err := os.Stat(socketPath("beat")); err != nil {
+		t.Errorf("replacement socket removed: %v", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestControlSpeaksWallTime(t *testing.T) {
+	saved, scale := configDirs, rootFlags.timeScale
+	t.Cleanup(func() { configDirs, rootFlags.timeScale = saved, scale })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+	resetStore(t)
+
+	// an engine far into its own time, where an hour passes in a wall minute
+	rootFlags.timeScale = 60
+	clock := hypnos.NewFakeClock(time.Now().Add(100 * time.Hour))
+	near := func(got, want time.Time) bool {
+		d := got.Sub(want)
+		return d > -time.Second && d < time.Second
+	}
+
+	// the deadline is stored in wall time for scan, snooze and extend, and reloaded in engine time
+	saveProbeMeta(&probeMeta{Probe: "beat"})
+	persistDeadline("beat", clock, t.Logf)(clock.Now().Add(time.Hour))
+	if meta, err := readProbeMeta("beat"); err != nil || !near(meta.NextFire, time.Now().Add(time.Minute)) {
+		t.Errorf("stored next firing %v, %v; want a minute from now", meta.NextFire, err)
+	}
+	cmd, err := reloadCommand("beat", clock)
+	if r, ok := cmd.(hypnos.Reschedule); err != nil || !ok || !near(r.At, clock.Now().Add(time.Hour)) {
+		t.Errorf("reload = %#v, %v; want an hour of engine time ahead", cmd, err)
+	}
+
+	// the socket reports and takes wall time as well
+	control := make(chan hypnos.Command)
+	cleanup, err := serveControl("beat", clock, control)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	got := make(chan hypnos.Command, 1)
+	go func() {
+		for cmd := range control {
+			if st, ok := cmd.(hypnos.Status); ok {
+				st.Reply <- hypnos.State{Next: clock.Now().Add(time.Hour), Remaining: time.Hour}
+				continue
+			}
+			got <- cmd
+		}
+	}()
+
+	state, err := callWorker("beat", controlRequest{Op: opStatus})
+	if err != nil || !near(state.Next, time.Now().Add(time.Minute)) || state.Remaining != time.Minute {
+		t.Errorf("status = %+v, %v; want the next firing a minute from now", state, err)
+	}
+	if _, err := callWorker("beat", controlRequest{Op: opSnooze, At: time.Now().Add(2 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := <-got; !near(cmd.(hypnos.Reschedule).At, clock.Now().Add(2*time.Hour)) {
+		t.Errorf("snooze delivered %#v, want two hours of engine time ahead", cmd)
+	}
+}
@@ -0,0 +1,348 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/DanielRivasMD/horus"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// probeSchemaVersion is stamped into every metadata document the store writes
+const probeSchemaVersion = 2
+
+// probeMigrations[i] upgrades a raw document from schema version i+1 to i+2
+var probeMigrations = []func(doc map[string]any){
+	// v1: files written before versioning; every field added since is optional, so only the stamp changes
+	func(doc map[string]any) {},
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// probeStore keeps the metadata of every probe and its run history. Writers never leave a
+// half-written document behind and Update is a locked read-modify-write, so workers and the CLI
+// can touch the same probe
+type probeStore interface {
+	Names() ([]string, error)
+	Load(name string) (*probeMeta, error)
+	Save(meta *probeMeta) error
+	Update(name string, update func(*probeMeta)) error
+	Remove(name string) error
+	Location(name string) string
+
+	AppendRun(rec hypnos.RunRecord) error
+	Runs(probe string, since time.Time) ([]hypnos.RunRecord, error)
+}
+
+// store backends, picked with HYPNOS_STORE or the `store` setting; workers inherit both
+const (
+	storeFile   = "file"
+	storeSQLite = "sqlite"
+)
+
+var (
+	storeOnce sync.Once
+	store     probeStore
+)
+
+func probes() probeStore {
+	storeOnce.Do(func() {
+		backend := os.Getenv("HYPNOS_STORE")
+		if backend == "" {
+			backend = settings.GetString("store")
+		}
+		switch backend {
+		case "", storeFile:
+			store = &fileStore{dir: configDirs.probe, history: configDirs.history}
+		case storeSQLite:
+			db, err := openSQLiteStore(filepath.Join(configDirs.state, "hypnos.db"))
+			horus.CheckErr(err, horus.WithOp("hypnos.store"), horus.WithCategory("io_error"), horus.WithMessage("opening sqlite store"))
+			store = db
+		default:
+			horus.CheckErr(
+				fmt.Errorf("unknown store %q (valid: %s, %s)", backend, storeFile, storeSQLite),
+				horus.WithOp("hypnos.store"),
+				horus.WithCategory("config_error"),
+				horus.WithFormatter(func(he *horus.Herror) string { return horus.OneLineErr(he.Err.Error()) }),
+			)
+		}
+	})
+	return store
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fileStore keeps one JSON document per probe, replaced by rename and guarded by a flock on
+// <dir>/.lock: shared for reads, exclusive for writes. Runs are appended to history/<probe>.jsonl
+type fileStore struct {
+	dir     string
+	history string
+}
+
+func (s *fileStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+func (s *fileStore) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *fileStore) Load(name string) (*probeMeta, error) {
+	unlock, err := s.lock(syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.read(name)
+}
+
+func (s *fileStore) Save(meta *probeMeta) error {
+	unlock, err := s.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write(meta)
+}
+
+func (s *fileStore) Update(name string, update func(*probeMeta)) error {
+	unlock, err := s.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	meta, err := s.read(name)
+	if err != nil {
+		return err
+	}
+	update(meta)
+	return s.write(meta)
+}
+
+// Location is where a probe's metadata lives, for messages
+func (s *fileStore) Location(name string) string {
+	return s.path(name)
+}
+
+func (s *fileStore) Remove(name string) error {
+	unlock, err := s.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return os.Remove(s.path(name))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *fileStore) lock(how int) (func(), error) {
+	unlock, err := flockFile(filepath.Join(s.dir, ".lock"), how)
+	if err != nil {
+		return nil, fmt.Errorf("locking probe store: %w", err)
+	}
+	return unlock, nil
+}
+
+// flockFile takes a flock on path, creating it and its directory as needed. The descriptor is
+// closed on exec, so spawned workers never hold the lock
+func flockFile(path string, how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *fileStore) read(name string) (*probeMeta, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+	if data, err = migrateProbeMeta(data); err != nil {
+		return nil, fmt.Errorf("probe %s: %w", name, err)
+	}
+
+	var meta probeMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("probe %s: %w", name, err)
+	}
+	return &meta, nil
+}
+
+// write replaces the document through a synced temp file and a rename, so readers see either the
+// old or the new metadata and never a partial one
+func (s *fileStore) write(meta *probeMeta) error {
+	meta.Version = probeSchemaVersion
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+meta.Probe+".json.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(meta.Probe))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *fileStore) AppendRun(rec hypnos.RunRecord) error {
+	if err := os.MkdirAll(s.history, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.history, rec.Probe+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Runs reads the history of one probe, or of every probe when probe is empty
+func (s *fileStore) Runs(probe string, since time.Time) ([]hypnos.RunRecord, error) {
+	paths := []string{filepath.Join(s.history, probe+".jsonl")}
+	if probe == "" {
+		var err error
+		if paths, err = filepath.Glob(filepath.Join(s.history, "*.jsonl")); err != nil {
+			return nil, err
+		}
+	}
+
+	var runs []hypnos.RunRecord
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			var rec hypnos.RunRecord
+			if json.Unmarshal(sc.Bytes(), &rec) != nil || rec.Started.Before(since) {
+				continue
+			}
+			runs = append(runs, rec)
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// migrateProbeMeta upgrades an older document to the current schema; unversioned files are v1
+func migrateProbeMeta(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 1
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+	switch {
+	case version == probeSchemaVersion:
+		return data, nil
+	case version > probeSchemaVersion:
+		return nil, fmt.Errorf("metadata schema v%d is newer than this hypnos (v%d)", version, probeSchemaVersion)
+	}
+
+	for v := version; v < probeSchemaVersion; v++ {
+		probeMigrations[v-1](doc)
+	}
+	doc["version"] = probeSchemaVersion
+	return json.Marshal(doc)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
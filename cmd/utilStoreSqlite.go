@@ -0,0 +1,218 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	_ "modernc.org/sqlite"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sqliteSchema keeps probe metadata as versioned JSON documents, so the migrations shared with the
+// file store apply unchanged, and runs as rows that can be filtered and aggregated
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS probes (
+	name TEXT PRIMARY KEY,
+	doc  TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS runs (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	probe      TEXT    NOT NULL,
+	iteration  INTEGER NOT NULL,
+	attempt    INTEGER NOT NULL,
+	started    INTEGER NOT NULL,
+	finished   INTEGER NOT NULL,
+	exit_code  INTEGER NOT NULL,
+	status     TEXT    NOT NULL,
+	error      TEXT    NOT NULL DEFAULT '',
+	last_line  TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS runs_probe_started ON runs (probe, started);
+`
+
+// sqliteStore is the embedded database backend. Write transactions take the database lock up
+// front (_txlock=immediate) and wait for each other through busy_timeout
+type sqliteStore struct {
+	db   *sql.DB
+	path string
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_txlock=immediate&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db, path: path}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *sqliteStore) Names() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM probes ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (s *sqliteStore) Load(name string) (*probeMeta, error) {
+	return loadSQLiteMeta(s.db, name)
+}
+
+func (s *sqliteStore) Save(meta *probeMeta) error {
+	return saveSQLiteMeta(s.db, meta)
+}
+
+func (s *sqliteStore) Update(name string, update func(*probeMeta)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	meta, err := loadSQLiteMeta(tx, name)
+	if err != nil {
+		return err
+	}
+	update(meta)
+	if err := saveSQLiteMeta(tx, meta); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Location(name string) string {
+	return fmt.Sprintf("%s (probes row %q)", s.path, name)
+}
+
+func (s *sqliteStore) Remove(name string) error {
+	res, err := s.db.Exec(`DELETE FROM probes WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *sqliteStore) AppendRun(rec hypnos.RunRecord) error {
+	_, err := s.db.Exec(
+		`INSERT INTO runs (probe, iteration, attempt, started, finished, exit_code, status, error, last_line)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Probe, rec.Iteration, rec.Attempt, rec.Started.UnixNano(), rec.Finished.UnixNano(),
+		rec.ExitCode, rec.Status, rec.Error, rec.LastLine,
+	)
+	return err
+}
+
+func (s *sqliteStore) Runs(probe string, since time.Time) ([]hypnos.RunRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT probe, iteration, attempt, started, finished, exit_code, status, error, last_line
+		 FROM runs WHERE (? = '' OR probe = ?) AND started >= ? ORDER BY started`,
+		probe, probe, since.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []hypnos.RunRecord
+	for rows.Next() {
+		var rec hypnos.RunRecord
+		var started, finished int64
+		if err := rows.Scan(&rec.Probe, &rec.Iteration, &rec.Attempt, &started, &finished,
+			&rec.ExitCode, &rec.Status, &rec.Error, &rec.LastLine); err != nil {
+			return nil, err
+		}
+		rec.Started, rec.Finished = time.Unix(0, started), time.Unix(0, finished)
+		rec.Elapsed = rec.Finished.Sub(rec.Started)
+		runs = append(runs, rec)
+	}
+	return runs, rows.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sqlQuerier is satisfied by both *sql.DB and *sql.Tx
+type sqlQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func loadSQLiteMeta(q sqlQuerier, name string) (*probeMeta, error) {
+	var doc []byte
+	err := q.QueryRow(`SELECT doc FROM probes WHERE name = ?`, name).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = migrateProbeMeta(doc); err != nil {
+		return nil, err
+	}
+
+	var meta probeMeta
+	if err := json.Unmarshal(doc, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func saveSQLiteMeta(q sqlQuerier, meta *probeMeta) error {
+	meta.Version = probeSchemaVersion
+	doc, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`INSERT INTO probes (name, doc) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET doc = excluded.doc`, meta.Probe, doc)
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
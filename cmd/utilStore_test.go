@@ -0,0 +1,354 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// storeBackend runs the store tests against one backend. reopen gives another handle on the same
+// data, as a second process would have; put and get bypass the store to reach the raw document
+type storeBackend struct {
+	name   string
+	open   func(t *testing.T) probeStore
+	reopen func(t *testing.T, s probeStore) probeStore
+	put    func(t *testing.T, s probeStore, name, doc string)
+	get    func(t *testing.T, s probeStore, name string) string
+}
+
+var storeBackends = []storeBackend{
+	{
+		name: storeFile,
+		open: func(t *testing.T) probeStore {
+			dir := t.TempDir()
+			return &fileStore{dir: filepath.Join(dir, "probe"), history: filepath.Join(dir, "history")}
+		},
+		reopen: func(t *testing.T, s probeStore) probeStore {
+			fs := s.(*fileStore)
+			return &fileStore{dir: fs.dir, history: fs.history}
+		},
+		put: func(t *testing.T, s probeStore, name, doc string) {
+			fs := s.(*fileStore)
+			if err := os.MkdirAll(fs.dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fs.path(name), []byte(doc), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		},
+		get: func(t *testing.T, s probeStore, name string) string {
+			data, err := os.ReadFile(s.(*fileStore).path(name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		},
+	},
+	{
+		name: storeSQLite,
+		open: func(t *testing.T) probeStore {
+			return openTestSQLite(t, filepath.Join(t.TempDir(), "hypnos.db"))
+		},
+		reopen: func(t *testing.T, s probeStore) probeStore {
+			return openTestSQLite(t, s.(*sqliteStore).path)
+		},
+		put: func(t *testing.T, s probeStore, name, doc string) {
+			if _, err := s.(*sqliteStore).db.Exec(`INSERT OR REPLACE INTO probes (name, doc) VALUES (?, ?)`, name, doc); err != nil {
+				t.Fatal(err)
+			}
+		},
+		get: func(t *testing.T, s probeStore, name string) string {
+			var doc string
+			if err := s.(*sqliteStore).db.QueryRow(`SELECT doc FROM probes WHERE name = ?`, name).Scan(&doc); err != nil {
+				t.Fatal(err)
+			}
+			return doc
+		},
+	},
+}
+
+func openTestSQLite(t *testing.T, path string) *sqliteStore {
+	s, err := openSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.db.Close() })
+	return s
+}
+
+// eachStore runs test once per backend
+func eachStore(t *testing.T, test func(t *testing.T, b storeBackend, s probeStore)) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) { test(t, b, b.open(t)) })
+	}
+}
+
+// resetStore drops the store picked by probes(), so the next call picks again
+func resetStore(t *testing.T) {
+	drop := func() {
+		if db, ok := store.(*sqliteStore); ok {
+			db.db.Close()
+		}
+		storeOnce, store = sync.Once{}, nil
+	}
+	drop()
+	t.Cleanup(drop)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestStoreSaveUpdateRemove(t *testing.T) {
+	eachStore(t, func(t *testing.T, b storeBackend, s probeStore) {
+		if _, err := s.Load("beat"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("load of a missing probe: %v", err)
+		}
+		if err := s.Update("beat", func(*probeMeta) {}); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("update of a missing probe: %v", err)
+		}
+
+		for _, name := range []string{"beat", "alpha"} {
+			if err := s.Save(&probeMeta{Probe: name, PID: 42, Vars: []string{"target=/data"}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if names, err := s.Names(); err != nil || !slices.Equal(names, []string{"alpha", "beat"}) {
+			t.Errorf("names %v, %v", names, err)
+		}
+
+		if err := s.Update("beat", func(m *probeMeta) { m.PID = 43 }); err != nil {
+			t.Fatal(err)
+		}
+		meta, err := s.Load("beat")
+		if err != nil || meta.PID != 43 || !slices.Equal(meta.Vars, []string{"target=/data"}) || meta.Version != probeSchemaVersion {
+			t.Errorf("after update: %+v, %v", meta, err)
+		}
+
+		if err := s.Remove("beat"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Remove("beat"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("second remove: %v", err)
+		}
+		if names, _ := s.Names(); !slices.Equal(names, []string{"alpha"}) {
+			t.Errorf("names after remove %v", names)
+		}
+	})
+}
+
+func TestStoreUpdateIsLocked(t *testing.T) {
+	eachStore(t, func(t *testing.T, b storeBackend, s probeStore) {
+		if err := s.Save(&probeMeta{Probe: "beat"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// every writer has a handle of its own, as separate processes would
+		const writers = 20
+		var wg sync.WaitGroup
+		for range writers {
+			other := b.reopen(t, s)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if err := other.Update("beat", func(m *probeMeta) { m.Iterations++ }); err != nil {
+					t.Error(err)
+				}
+			}()
+			// readers never see a half-written document
+			go func() {
+				defer wg.Done()
+				if _, err := s.Load("beat"); err != nil {
+					t.Errorf("load during updates: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if meta, err := s.Load("beat"); err != nil || meta.Iterations != writers {
+			t.Fatalf("after %d updates: %+v, %v", writers, meta, err)
+		}
+
+		// the temp files renamed into place are all gone
+		fs, ok := s.(*fileStore)
+		if !ok {
+			return
+		}
+		entries, err := os.ReadDir(fs.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if e.Name() != "beat.json" && e.Name() != ".lock" {
+				t.Errorf("left in the store: %s", e.Name())
+			}
+		}
+	})
+}
+
+func TestStoreMigratesOldDocuments(t *testing.T) {
+	eachStore(t, func(t *testing.T, b storeBackend, s probeStore) {
+		// written before versioning: no version field at all
+		b.put(t, s, "beat", `{"probe": "beat", "pid": 42, "log_path": "/tmp/beat.log", "duration": 60000000000}`)
+
+		meta, err := s.Load("beat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.Version != probeSchemaVersion || meta.PID != 42 || meta.LogPath != "/tmp/beat.log" || meta.Duration != time.Minute {
+			t.Errorf("migrated %+v", meta)
+		}
+
+		// the upgrade is written back with the next update
+		if err := s.Update("beat", func(m *probeMeta) { m.PID = 43 }); err != nil {
+			t.Fatal(err)
+		}
+		if doc := strings.ReplaceAll(b.get(t, s, "beat"), " ", ""); !strings.Contains(doc, fmt.Sprintf(`"version":%d`, probeSchemaVersion)) {
+			t.Errorf("stored document not stamped:\n%s", doc)
+		}
+	})
+}
+
+func TestProbeMigrationsCoverEveryVersion(t *testing.T) {
+	if len(probeMigrations) != probeSchemaVersion-1 {
+		t.Fatalf("%d migrations for schema v%d, want one per version step", len(probeMigrations), probeSchemaVersion)
+	}
+	for v := 1; v < probeSchemaVersion; v++ {
+		data, err := migrateProbeMeta([]byte(fmt.Sprintf(`{"version": %d, "probe": "beat"}`, v)))
+		if err != nil {
+			t.Fatalf("v%d: %v", v, err)
+		}
+		if !strings.Contains(string(data), fmt.Sprintf(`"version":%d`, probeSchemaVersion)) {
+			t.Errorf("v%d upgraded to %s", v, data)
+		}
+	}
+}
+
+func TestStoreRefusesNewerDocuments(t *testing.T) {
+	eachStore(t, func(t *testing.T, b storeBackend, s probeStore) {
+		newer := fmt.Sprintf(`{"version": %d, "probe": "beat", "pid": 42}`, probeSchemaVersion+1)
+		b.put(t, s, "beat", newer)
+
+		if _, err := s.Load("beat"); err == nil || !strings.Contains(err.Error(), "newer than this hypnos") {
+			t.Errorf("load: got %v, want a refusal", err)
+		}
+		// an update must not overwrite what a newer hypnos wrote
+		if err := s.Update("beat", func(m *probeMeta) { m.PID = 1 }); err == nil {
+			t.Error("update of a newer document succeeded")
+		}
+		if doc := b.get(t, s, "beat"); doc != newer {
+			t.Errorf("newer document rewritten:\n%s", doc)
+		}
+	})
+}
+
+func TestStoreRuns(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(probe string, minute int, status string) hypnos.RunRecord {
+		started := at.Add(time.Duration(minute) * time.Minute)
+		return hypnos.RunRecord{
+			Probe: probe, Iteration: 1, Attempt: 1, Status: status, LastLine: "done",
+			Started: started, Finished: started.Add(time.Second), Elapsed: time.Second,
+		}
+	}
+
+	eachStore(t, func(t *testing.T, b storeBackend, s probeStore) {
+		for _, rec := range []hypnos.RunRecord{
+			run("beat", 2, hypnos.StatusSuccess),
+			run("mail", 1, hypnos.StatusFailure),
+			run("beat", 0, hypnos.StatusTimeout),
+		} {
+			if err := s.AppendRun(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		beat, err := s.Runs("beat", time.Time{})
+		if err != nil || len(beat) != 2 || beat[0].Status != hypnos.StatusTimeout || beat[1].Status != hypnos.StatusSuccess {
+			t.Fatalf("beat runs %+v, %v", beat, err)
+		}
+		if got := beat[1]; !got.Started.Equal(at.Add(2*time.Minute)) || got.Elapsed != time.Second || got.LastLine != "done" {
+			t.Errorf("run read back as %+v", got)
+		}
+
+		all, err := s.Runs("", at.Add(time.Minute))
+		if err != nil || len(all) != 2 || all[0].Probe != "mail" || all[1].Probe != "beat" {
+			t.Errorf("runs since minute 1: %+v, %v", all, err)
+		}
+		if none, err := s.Runs("nobody", time.Time{}); err != nil || len(none) != 0 {
+			t.Errorf("runs of an unknown probe: %+v, %v", none, err)
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestProbesPicksBackend(t *testing.T) {
+	savedDirs, savedSettings := configDirs, settings
+	t.Cleanup(func() { configDirs, settings = savedDirs, savedSettings })
+	configDirs.setRoots(t.TempDir(), t.TempDir())
+
+	tests := []struct {
+		env, setting string
+		want         string
+	}{
+		{"", "", storeFile},
+		{"", storeSQLite, storeSQLite},
+		{storeSQLite, "", storeSQLite},
+		// the environment, which workers inherit, wins over the setting
+		{storeFile, storeSQLite, storeFile},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("env=%q setting=%q", tt.env, tt.setting), func(t *testing.T) {
+			resetStore(t)
+			t.Setenv("HYPNOS_STORE", tt.env)
+			settings = viper.New()
+			if tt.setting != "" {
+				settings.Set("store", tt.setting)
+			}
+
+			got := storeFile
+			if _, ok := probes().(*sqliteStore); ok {
+				got = storeSQLite
+			}
+			if got != tt.want {
+				t.Errorf("picked %s, want %s", got, tt.want)
+			}
+
+			// the picked backend is the one that holds what is saved
+			saveProbeMeta(&probeMeta{Probe: "beat", PID: 42})
+			if meta, err := readProbeMeta("beat"); err != nil || meta.PID != 42 {
+				t.Errorf("read back %+v, %v", meta, err)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,120 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseVars turns KEY=VALUE pairs into a map, later pairs overriding earlier ones
+func parseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, kv := range pairs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid var %q, expected KEY=VALUE", kv)
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// varToken matches a `{{.name}}` reference, spaces allowed inside the braces
+var varToken = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// applyVars substitutes the workflow's vars into the script, probe and log name, so
+// `script = "backup.sh {{.target}}"` is resolved once at launch. A reference to a var that is not
+// defined refuses the launch; without vars nothing is substituted, so a workflow keeps braces
+// such as docker --format '{{.Names}}' as written
+func applyVars(cfg *configPaths) error {
+	if len(cfg.vars) == 0 {
+		return nil
+	}
+
+	vars, err := parseVars(cfg.vars)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		key string
+		dst *string
+	}{
+		{"script", &cfg.script},
+		{"probe", &cfg.probe},
+		{"log", &cfg.log},
+	} {
+		var missing []string
+		if *field.dst, missing = renderVars(*field.dst, vars); len(missing) > 0 {
+			return fmt.Errorf("%s (define it in vars or pass --var %s=...)", undefinedVars(field.key, missing), missing[0])
+		}
+	}
+
+	// keep one resolved pair per key so metadata and the worker see the effective values
+	cfg.vars = envPairs(toAnyMap(vars))
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// renderVars substitutes the defined vars in text, returning the names referenced but undefined
+func renderVars(text string, vars map[string]string) (string, []string) {
+	var missing []string
+	out := varToken.ReplaceAllStringFunc(text, func(token string) string {
+		name := varToken.FindStringSubmatch(token)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return token
+	})
+	return out, missing
+}
+
+// undefinedVars words the references a key makes to undefined vars, for launch and check alike
+func undefinedVars(key string, missing []string) string {
+	quoted := make([]string, len(missing))
+	for i, name := range missing {
+		quoted[i] = strconv.Quote(name)
+	}
+	if len(missing) == 1 {
+		return fmt.Sprintf("%s uses undefined var %s", key, quoted[0])
+	}
+	return fmt.Sprintf("%s uses undefined vars %s", key, strings.Join(quoted, ", "))
+}
+
+func toAnyMap(vars map[string]string) map[string]any {
+	out := make(map[string]any, len(vars))
+	for k, v := range vars {
+		out[k] = v
+	}
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,96 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestApplyVars(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		vars   []string
+		want   string
+	}{
+		{"defined var", "backup.sh {{.target}}", []string{"target=/data"}, "backup.sh /data"},
+		{"spaces in the braces", "backup.sh {{ .target }}", []string{"target=/data"}, "backup.sh /data"},
+		{"later value wins", "backup.sh {{.target}}", []string{"target=/data", "target=/srv"}, "backup.sh /srv"},
+		{"literal braces without vars", "docker ps --format '{{.Names}}'", nil, "docker ps --format '{{.Names}}'"},
+		{"template actions untouched", `echo '{{if .x}}{{end}} {{ "{{" }}'`, []string{"x=1"}, `echo '{{if .x}}{{end}} {{ "{{" }}'`},
+		{"mustache untouched", "echo {{name}} {{.name}}", []string{"name=n"}, "echo {{name}} n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := configPaths{script: tt.script, probe: "p", log: "p", vars: tt.vars}
+			if err := applyVars(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.script != tt.want {
+				t.Errorf("script %q, want %q", cfg.script, tt.want)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestApplyVarsRefusesUndefined(t *testing.T) {
+	cfg := configPaths{script: "backup.sh {{.target}} {{.dest}} {{.target}}", probe: "p", log: "p", vars: []string{"dest=/srv"}}
+	err := applyVars(&cfg)
+	if err == nil || !strings.Contains(err.Error(), `script uses undefined var "target"`) || !strings.Contains(err.Error(), "--var target=") {
+		t.Errorf("got %v, want a refusal naming target", err)
+	}
+
+	cfg = configPaths{script: "s", probe: "backup-{{.a}}-{{.b}}", log: "p", vars: []string{"x=1"}}
+	if err := applyVars(&cfg); err == nil || !strings.Contains(err.Error(), `probe uses undefined vars "a", "b"`) {
+		t.Errorf("got %v, want a refusal naming a and b", err)
+	}
+}
+
+func TestCheckReportsUndefinedVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.toml")
+	config := `[workflows.backup]
+script = "backup.sh {{.target}}"
+log = "backup-{{.name}}"
+vars = { name = "data" }
+
+[workflows.docker]
+script = "docker ps --format '{{.Names}}'"
+`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, issue := range checkConfigFiles([]string{path}, nil) {
+		got = append(got, issue.String())
+	}
+	want := []string{path + `:2: workflow "backup": script uses undefined var "target"`}
+	if !slices.Equal(got, want) {
+		t.Errorf("issues %q, want %q", got, want)
+	}
+}
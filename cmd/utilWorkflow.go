@@ -0,0 +1,214 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DanielRivasMD/Hypnos/hypnos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readWorkflow resolves a workflow into launcher settings without going through cobra flags,
+// for callers that run workflows in-process such as routines; vars override the workflow's vars
+func readWorkflow(reg *configRegistry, name string, vars []string) (configPaths, error) {
+	entry, err := reg.workflow(name)
+	if err != nil {
+		return configPaths{}, err
+	}
+	wf := entry.viper()
+
+	cp := configPaths{
+		config:       name,
+		probe:        name,
+		log:          name,
+		duration:     time.Hour,
+		retryBackoff: 30 * time.Second,
+	}
+	for _, f := range workflowSchema {
+		if err := f.apply(&cp, wf); err != nil {
+			return configPaths{}, fmt.Errorf("workflow %s: %w", name, err)
+		}
+	}
+
+	cp.env = entry.env()
+	cp.vars = append(entry.vars(), vars...)
+	if err := applyVars(&cp); err != nil {
+		return configPaths{}, fmt.Errorf("workflow %s: %w", name, err)
+	}
+
+	applyWorkflowDefaults(&cp)
+
+	if cp.script == "" && !cp.notify {
+		return configPaths{}, fmt.Errorf("workflow %s has no script", name)
+	}
+
+	for _, err := range []error{
+		validateShell(cp.shell),
+		hypnos.ValidateOverlap(cp.overlap),
+		hypnos.ValidateNotifyOn(cp.notifyOn),
+		hypnos.ValidateNotifyTemplate("notify_title", cp.notifyTitle),
+		hypnos.ValidateNotifyTemplate("notify_message", cp.notifyMessage),
+	} {
+		if err != nil {
+			return configPaths{}, fmt.Errorf("workflow %s: %w", name, err)
+		}
+	}
+
+	return cp, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// configDuration parses a duration key, falling back to def when unset
+func configDuration(cfg *viper.Viper, key string, def time.Duration) (time.Duration, error) {
+	if !cfg.IsSet(key) {
+		return def, nil
+	}
+	val := cfg.GetString(key)
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %q: %w", key, err)
+	}
+	return d, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// keyKind is the TOML value type expected for a workflow key
+type keyKind int
+
+const (
+	kindString keyKind = iota
+	kindBool
+	kindInt
+	kindDuration
+	kindIntList
+	kindTable
+	kindTableList
+)
+
+func (k keyKind) String() string {
+	return [...]string{"string", "boolean", "integer", "duration string", "array of integers", "table", "array of tables"}[k]
+}
+
+// workflowField ties a key of a [workflows.*] table to the configPaths field it fills and the
+// launcher flag that overrides it; keys without a field are resolved by the registry instead
+type workflowField struct {
+	key   string
+	kind  keyKind
+	flag  string
+	field func(*configPaths) any
+}
+
+// workflowSchema is the schema of a [workflows.*] table
+var workflowSchema = []workflowField{
+	{"extends", kindString, "", nil},
+	{"script", kindString, "script", func(c *configPaths) any { return &c.script }},
+	{"probe", kindString, "probe", func(c *configPaths) any { return &c.probe }},
+	{"group", kindString, "group", func(c *configPaths) any { return &c.group }},
+	{"log", kindString, "log", func(c *configPaths) any { return &c.log }},
+	{"duration", kindDuration, "duration", func(c *configPaths) any { return &c.duration }},
+	{"recurrent", kindBool, "recurrent", func(c *configPaths) any { return &c.recurrent }},
+	{"iterations", kindInt, "iterations", func(c *configPaths) any { return &c.iterations }},
+	{"notify_only", kindBool, "notify-only", func(c *configPaths) any { return &c.notify }},
+	{"carbonite", kindBool, "carbonite", func(c *configPaths) any { return &c.carbonite }},
+	{"env", kindTable, "", nil},
+	{"env_file", kindString, "env-file", func(c *configPaths) any { return &c.envFile }},
+	{"workdir", kindString, "workdir", func(c *configPaths) any { return &c.workdir }},
+	{"shell", kindString, "shell", func(c *configPaths) any { return &c.shell }},
+	{"timeout", kindDuration, "timeout", func(c *configPaths) any { return &c.timeout }},
+	{"overlap", kindString, "overlap", func(c *configPaths) any { return &c.overlap }},
+	{"retries", kindInt, "retries", func(c *configPaths) any { return &c.retries }},
+	{"retry_backoff", kindDuration, "retry-backoff", func(c *configPaths) any { return &c.retryBackoff }},
+	{"retry_backoff_max", kindDuration, "retry-backoff-max", func(c *configPaths) any { return &c.retryBackoffMax }},
+	{"success_codes", kindIntList, "success-codes", func(c *configPaths) any { return &c.successCodes }},
+	{"notify_on", kindString, "notify-on", func(c *configPaths) any { return &c.notifyOn }},
+	{"notify_title", kindString, "notify-title", func(c *configPaths) any { return &c.notifyTitle }},
+	{"notify_message", kindString, "notify-message", func(c *configPaths) any { return &c.notifyMessage }},
+	{"notify_timeout", kindBool, "notify-timeout", func(c *configPaths) any { return &c.notifyTimeout }},
+	{"on_success", kindString, "on-success", func(c *configPaths) any { return &c.onSuccess }},
+	{"on_failure", kindString, "on-failure", func(c *configPaths) any { return &c.onFailure }},
+	{"vars", kindTable, "", nil},
+	{"singleton", kindBool, "", func(c *configPaths) any { return &c.singleton }},
+}
+
+// workflowKeys maps each workflow key to its expected type, for `hypnos check`
+var workflowKeys = func() map[string]keyKind {
+	keys := make(map[string]keyKind, len(workflowSchema))
+	for _, f := range workflowSchema {
+		keys[f.key] = f.kind
+	}
+	return keys
+}()
+
+// apply copies the key from a workflow into its configPaths field, leaving the field alone when unset
+func (f workflowField) apply(cfg *configPaths, wf *viper.Viper) error {
+	if f.field == nil || !wf.IsSet(f.key) {
+		return nil
+	}
+	switch dst := f.field(cfg).(type) {
+	case *string:
+		*dst = wf.GetString(f.key)
+	case *bool:
+		*dst = wf.GetBool(f.key)
+	case *int:
+		*dst = wf.GetInt(f.key)
+	case *[]int:
+		*dst = wf.GetIntSlice(f.key)
+	case *time.Duration:
+		d, err := configDuration(wf, f.key, *dst)
+		if err != nil {
+			return err
+		}
+		*dst = d
+	default:
+		return fmt.Errorf("workflow key %q has no handler for %T", f.key, dst)
+	}
+	return nil
+}
+
+// bindWorkflow fills the launcher from a workflow: keys with a flag go through bindFlag, so flags
+// given on the command line win, and the rest are copied straight into cfg
+func bindWorkflow(cmd *cobra.Command, wf *viper.Viper, cfg *configPaths) error {
+	for _, f := range workflowSchema {
+		if f.flag != "" {
+			bindFlag(cmd, f.flag, wf)
+			continue
+		}
+		if err := f.apply(cfg, wf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// routineKeys is the schema of a [routines.*] table
+var routineKeys = map[string]keyKind{
+	"group":      kindString,
+	"repeat":     kindInt,
+	"steps":      kindTableList,
+	"long_break": kindTable,
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,246 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMain(m *testing.M) {
+	InitDocs()
+	// persistent flags, such as --time-scale, get their defaults
+	GetRootCmd()
+	os.Exit(m.Run())
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// flagTypes is the pflag type each workflow key kind must be bound to
+var flagTypes = map[keyKind]string{
+	kindString:   "string",
+	kindBool:     "bool",
+	kindInt:      "int",
+	kindDuration: "duration",
+	kindIntList:  "intSlice",
+}
+
+func TestWorkflowSchemaFlags(t *testing.T) {
+	commands := map[string]*cobra.Command{
+		"hibernate-launcher": HibernateLauncherCmd(),
+		"hibernate-worker":   HibernateWorkerCmd(),
+	}
+
+	for _, name := range sortedKeys(commands) {
+		cmd := commands[name]
+		for _, f := range workflowSchema {
+			if f.flag == "" {
+				continue
+			}
+			t.Run(name+"/"+f.key, func(t *testing.T) {
+				flag := cmd.Flags().Lookup(f.flag)
+				if flag == nil {
+					t.Fatalf("no --%s flag for key %q", f.flag, f.key)
+				}
+				if got, want := flag.Value.Type(), flagTypes[f.kind]; got != want {
+					t.Errorf("--%s is %s, key %q is %s", f.flag, got, f.key, want)
+				}
+				if f.field == nil {
+					t.Errorf("key %q has a flag but no configPaths field", f.key)
+				}
+			})
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestBindWorkflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		toml  string
+		args  []string
+		check func(cfg configPaths) bool
+	}{
+		{
+			name:  "group",
+			toml:  `group = "work"`,
+			check: func(cfg configPaths) bool { return cfg.group == "work" },
+		},
+		{
+			name:  "notify only",
+			toml:  `notify_only = true`,
+			check: func(cfg configPaths) bool { return cfg.notify },
+		},
+		{
+			name:  "flag wins over workflow",
+			toml:  `group = "work"`,
+			args:  []string{"--group", "home"},
+			check: func(cfg configPaths) bool { return cfg.group == "home" },
+		},
+		{
+			name:  "hyphenated flag from snake case key",
+			toml:  `env_file = "/tmp/env"`,
+			check: func(cfg configPaths) bool { return cfg.envFile == "/tmp/env" },
+		},
+		{
+			name:  "duration",
+			toml:  `duration = "25m"`,
+			check: func(cfg configPaths) bool { return cfg.duration == 25*time.Minute },
+		},
+		{
+			name:  "unset duration keeps the flag default",
+			toml:  `script = "true"`,
+			check: func(cfg configPaths) bool { return cfg.duration == time.Hour && cfg.retryBackoff == 30*time.Second },
+		},
+		{
+			name:  "success codes",
+			toml:  `success_codes = [0, 3]`,
+			check: func(cfg configPaths) bool { return reflect.DeepEqual(cfg.successCodes, []int{0, 3}) },
+		},
+		{
+			name:  "key without a flag",
+			toml:  `singleton = true`,
+			check: func(cfg configPaths) bool { return cfg.singleton },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			launcher = configPaths{}
+			cmd := HibernateLauncherCmd()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			wf := viper.New()
+			wf.SetConfigType("toml")
+			if err := wf.ReadConfig(strings.NewReader(tt.toml)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := bindWorkflow(cmd, wf, &launcher); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(launcher) {
+				t.Errorf("unexpected launcher after binding %q: %+v", tt.toml, launcher)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestReadWorkflow(t *testing.T) {
+	const config = `
+[defaults]
+group = "work"
+
+[workflows.base]
+duration = "10m"
+notify_only = true
+
+[workflows.child]
+extends = "base"
+script = "echo {{.who}}"
+vars = { who = "world" }
+
+[workflows.bare]
+duration = "5m"
+notify_only = false
+
+[workflows.broken]
+script = "true"
+timeout = "soon"
+`
+	path := filepath.Join(t.TempDir(), "tasks.toml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reg := loadRegistryFiles([]string{path})
+
+	tests := []struct {
+		name     string
+		workflow string
+		vars     []string
+		want     configPaths
+		err      string
+	}{
+		{
+			name:     "notify only needs no script",
+			workflow: "base",
+			want:     configPaths{group: "work", duration: 10 * time.Minute, notify: true},
+		},
+		{
+			name:     "extends and vars",
+			workflow: "child",
+			want:     configPaths{group: "work", duration: 10 * time.Minute, notify: true, script: "echo world"},
+		},
+		{
+			name:     "var override",
+			workflow: "child",
+			vars:     []string{"who=you"},
+			want:     configPaths{group: "work", duration: 10 * time.Minute, notify: true, script: "echo you"},
+		},
+		{
+			name:     "missing script",
+			workflow: "bare",
+			err:      "workflow bare has no script",
+		},
+		{
+			name:     "bad duration",
+			workflow: "broken",
+			err:      `invalid duration for "timeout"`,
+		},
+		{
+			name:     "unknown workflow",
+			workflow: "nope",
+			err:      "workflow nope not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := readWorkflow(reg, tt.workflow, tt.vars)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := configPaths{group: cfg.group, duration: cfg.duration, notify: cfg.notify, script: cfg.script}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
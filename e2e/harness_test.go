@@ -0,0 +1,250 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package e2e drives the hypnos binary as a user would: every test gets its own HYPNOS_HOME,
+// launches real workers and checks metadata, logs and cleanup. Notifications go to the probe
+// log through the "log" notifier, so no desktop is needed. Skipped under -short
+package e2e
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// binary is the hypnos executable built once for the whole run
+var binary string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "hypnos-e2e-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "hypnos")
+	build := exec.Command("go", "build", "-o", binary, "..")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "building hypnos:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// how long to wait for a worker to reach an expected state
+const settle = 10 * time.Second
+
+// harness is one isolated hypnos installation
+type harness struct {
+	t    *testing.T
+	home string
+	env  []string
+}
+
+// probeMeta is the part of the stored metadata the tests look at
+type probeMeta struct {
+	Probe    string    `json:"probe"`
+	Workflow string    `json:"workflow"`
+	Group    string    `json:"group"`
+	PID      int       `json:"pid"`
+	LogPath  string    `json:"log_path"`
+	NextFire time.Time `json:"next_fire"`
+	Daemon   bool      `json:"daemon"`
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("end-to-end test")
+	}
+
+	h := &harness{t: t, home: t.TempDir()}
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "HYPNOS_") {
+			h.env = append(h.env, kv)
+		}
+	}
+	h.env = append(h.env, "HYPNOS_HOME="+h.home)
+
+	h.run("prime")
+	h.write("hypnos.toml", `notifier = "log"`+"\n")
+	t.Cleanup(h.stopAll)
+	return h
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// write creates a file under the hypnos home
+func (h *harness) write(name, content string) {
+	h.t.Helper()
+	path := filepath.Join(h.home, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// config writes a workflow file into the config dir
+func (h *harness) config(content string) {
+	h.write(filepath.Join("config", "e2e.toml"), content)
+}
+
+// exec runs hypnos with extra environment entries, returning combined output
+func (h *harness) exec(env []string, args ...string) (string, error) {
+	h.t.Helper()
+	return h.execIn("", env, args...)
+}
+
+// execIn is exec from another working directory
+func (h *harness) execIn(dir string, env []string, args ...string) (string, error) {
+	h.t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append(append([]string{}, h.env...), env...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// run runs hypnos and fails the test when it exits non-zero
+func (h *harness) run(args ...string) string {
+	h.t.Helper()
+	out, err := h.exec(nil, args...)
+	if err != nil {
+		h.t.Fatalf("hypnos %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// fail runs hypnos and fails the test unless it exits non-zero
+func (h *harness) fail(args ...string) string {
+	h.t.Helper()
+	out, err := h.exec(nil, args...)
+	if err == nil {
+		h.t.Fatalf("hypnos %s succeeded, expected a failure\n%s", strings.Join(args, " "), out)
+	}
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (h *harness) metaPath(probe string) string {
+	return filepath.Join(h.home, "probe", probe+".json")
+}
+
+func (h *harness) meta(probe string) probeMeta {
+	h.t.Helper()
+	data, err := os.ReadFile(h.metaPath(probe))
+	if err != nil {
+		h.t.Fatalf("reading metadata of %s: %v", probe, err)
+	}
+	var meta probeMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		h.t.Fatalf("parsing metadata of %s: %v", probe, err)
+	}
+	return meta
+}
+
+func (h *harness) hasMeta(probe string) bool {
+	_, err := os.Stat(h.metaPath(probe))
+	return err == nil
+}
+
+func (h *harness) log(name string) string {
+	data, _ := os.ReadFile(filepath.Join(h.home, "log", name+".log"))
+	return string(data)
+}
+
+// waitFor polls cond until it holds, failing the test after settle
+func (h *harness) waitFor(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(settle)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// waitLog waits until the log of name contains want at least n times
+func (h *harness) waitLog(name, want string, n int) {
+	h.t.Helper()
+	h.waitFor(fmt.Sprintf("%d × %q in %s.log", n, want, name), func() bool {
+		return strings.Count(h.log(name), want) >= n
+	})
+}
+
+// stopAll stops whatever the test left running, daemon included, by hypnos first and by signal
+// as a fallback
+func (h *harness) stopAll() {
+	entries, _ := os.ReadDir(filepath.Join(h.home, "probe"))
+	var pids []int
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok {
+			if data, err := os.ReadFile(h.metaPath(name)); err == nil {
+				var meta probeMeta
+				if json.Unmarshal(data, &meta) == nil && meta.PID > 0 {
+					pids = append(pids, meta.PID)
+				}
+			}
+		}
+	}
+	_, _ = h.exec(nil, "cryostasis", "--all")
+	_, _ = h.exec(nil, "daemon", "stop")
+	for _, pid := range pids {
+		if alive(pid) {
+			if p, err := os.FindProcess(pid); err == nil {
+				_ = p.Kill()
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alive reports whether pid is a running process; exited workers may linger as zombies
+func alive(pid int) bool {
+	out, err := exec.Command("ps", "-o", "state=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return false
+	}
+	state := strings.TrimSpace(string(out))
+	return state != "" && !strings.HasPrefix(state, "Z")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,640 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package e2e
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestHibernateScanCryostasis(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo beat"
+duration = "200ms"
+recurrent = true
+group = "e2e"
+`)
+
+	out := h.run("hibernate", "beat")
+	if !strings.Contains(out, "spawned downtime") {
+		t.Fatalf("unexpected launch output:\n%s", out)
+	}
+
+	meta := h.meta("beat")
+	if meta.Workflow != "beat" || meta.Group != "e2e" || meta.PID == 0 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	h.waitLog("beat", "▸ notification: Hypnos-beat: Downtime complete", 2)
+	if !strings.Contains(h.log("beat"), "beat\n") {
+		t.Error("script output missing from the log")
+	}
+
+	scan := h.run("scan")
+	if !strings.Contains(scan, "beat") || !strings.Contains(scan, "hibernating") {
+		t.Errorf("scan does not show the running probe:\n%s", scan)
+	}
+	if fields := strings.Fields(strings.Split(scan, "\n")[1]); len(fields) < 3 || fields[1] != "e2e" || fields[2] != fmt.Sprint(meta.PID) {
+		t.Errorf("scan row out of line with its header:\n%s", scan)
+	}
+
+	h.run("cryostasis", "beat")
+	h.waitFor("worker to exit", func() bool { return !alive(meta.PID) })
+	if h.hasMeta("beat") {
+		t.Error("metadata left behind after cryostasis")
+	}
+	if _, err := os.Stat(meta.LogPath); !os.IsNotExist(err) {
+		t.Error("log left behind after cryostasis")
+	}
+	if scan := h.run("scan"); strings.Contains(scan, "beat") {
+		t.Errorf("scan still lists the probe:\n%s", scan)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestOneShotProbe(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.once]
+script = "echo result-$((40 + 2))"
+duration = "100ms"
+notify_message = "{{.Status}}: {{.LastLine}}"
+`)
+
+	h.run("hibernate", "once")
+	meta := h.meta("once")
+	h.waitLog("once", "fully complete (ran 1 times)", 1)
+	h.waitFor("worker to exit", func() bool { return !alive(meta.PID) })
+
+	log := h.log("once")
+	for _, want := range []string{"result-42", "▸ notification: Hypnos-once: success: result-42"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log lacks %q:\n%s", want, log)
+		}
+	}
+	if scan := h.run("scan"); !strings.Contains(scan, "mortem") {
+		t.Errorf("finished probe not shown as mortem:\n%s", scan)
+	}
+	if history := h.run("history", "--probe", "once"); !strings.Contains(history, "once") {
+		t.Errorf("history lacks the run:\n%s", history)
+	}
+
+	// a finished probe does not hold on to its name
+	h.run("hibernate", "once")
+	h.waitLog("once", "fully complete (ran 1 times)", 2)
+}
+
+func TestFailureIsReported(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.broken]
+script = "echo oops; exit 3"
+duration = "100ms"
+retries = 1
+retry_backoff = "100ms"
+notify_message = "{{.Status}} exit {{.ExitCode}} after {{.Attempts}}"
+`)
+
+	h.run("hibernate", "broken")
+	h.waitLog("broken", "fully complete", 1)
+
+	log := h.log("broken")
+	for _, want := range []string{"▸ attempt 2/2", "▸ giving up after 2 attempts", "▸ notification: Hypnos-broken: failure exit 3 after 2"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log lacks %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestEnvStaysOffArgv(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.secret]
+script = "echo token=$API_TOKEN"
+duration = "1h"
+recurrent = true
+env = { API_TOKEN = "s3cr3t" }
+`)
+
+	h.run("hibernate", "secret")
+	meta := h.meta("secret")
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", meta.PID))
+	if err != nil {
+		t.Skipf("no /proc: %v", err)
+	}
+	if strings.Contains(string(cmdline), "s3cr3t") {
+		t.Errorf("env value visible on the worker command line: %q", cmdline)
+	}
+
+	h.run("trigger", "secret")
+	h.waitLog("secret", "token=s3cr3t", 1)
+	if strings.Contains(h.log("secret"), "HYPNOS_WORKER_ENV") {
+		t.Error("handover variable leaked into the log")
+	}
+}
+
+func TestTimeScale(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.hourly]
+script = "echo tick"
+duration = "1h"
+iterations = 2
+`)
+
+	h.run("--time-scale", "3600", "hibernate", "hourly")
+	h.waitLog("hourly", "fully complete (ran 2 times)", 1)
+
+	// the stored next firing and the control commands are in wall time
+	h.write("config/daily.toml", `
+[workflows.daily]
+script = "echo tick"
+duration = "24h"
+recurrent = true
+`)
+	h.run("--time-scale", "60", "hibernate", "daily")
+	soon := func(want time.Duration) {
+		t.Helper()
+		h.waitFor(fmt.Sprintf("next firing in %s", want), func() bool {
+			in := time.Until(h.meta("daily").NextFire)
+			return in <= want && in > want-time.Minute
+		})
+	}
+	soon(24 * time.Minute)
+	h.run("--time-scale", "60", "snooze", "daily", "2h")
+	soon(2 * time.Minute)
+	h.run("--time-scale", "60", "extend", "daily", "+1h")
+	soon(3 * time.Minute)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestGroupLaunchAndCryostasis(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[defaults]
+duration = "200ms"
+recurrent = true
+script = "true"
+
+[workflows.one]
+group = "batch"
+
+[workflows.two]
+group = "batch"
+
+[workflows.other]
+group = "solo"
+`)
+
+	h.run("hibernate", "--group", "batch")
+	h.run("hibernate", "other")
+	one, two, other := h.meta("one"), h.meta("two"), h.meta("other")
+	h.waitLog("one", "notification:", 1)
+	h.waitLog("two", "notification:", 1)
+
+	h.run("cryostasis", "--group", "batch")
+	h.waitFor("batch workers to exit", func() bool { return !alive(one.PID) && !alive(two.PID) })
+	if h.hasMeta("one") || h.hasMeta("two") {
+		t.Error("batch metadata left behind")
+	}
+	if !alive(other.PID) || !h.hasMeta("other") {
+		t.Error("cryostasis --group stopped a probe outside the group")
+	}
+}
+
+func TestNameCollision(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "true"
+duration = "200ms"
+recurrent = true
+log = "pulse"
+`)
+
+	h.run("hibernate", "beat")
+	first := h.meta("beat")
+
+	if out := h.fail("hibernate", "beat"); !strings.Contains(out, "already running") {
+		t.Errorf("collision not reported:\n%s", out)
+	}
+
+	h.run("hibernate", "beat", "--unique")
+	if second := h.meta("beat-2"); second.PID == first.PID || !alive(second.PID) {
+		t.Errorf("--unique did not start a second worker: %+v", second)
+	} else if filepath.Base(second.LogPath) != "pulse-2.log" {
+		t.Errorf("--unique instance logs to %s, want pulse-2.log", second.LogPath)
+	}
+
+	// concurrent launchers each settle on a name of their own
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := h.exec(nil, "hibernate", "beat", "--unique"); err != nil {
+				t.Errorf("concurrent --unique: %v\n%s", err, out)
+			}
+		}()
+	}
+	wg.Wait()
+	pids := make(map[int]bool)
+	for _, name := range []string{"beat", "beat-2", "beat-3", "beat-4", "beat-5"} {
+		pids[h.meta(name).PID] = true
+	}
+	if len(pids) != 5 {
+		t.Errorf("concurrent --unique launches shared a probe name: %d distinct workers", len(pids))
+	}
+
+	h.run("hibernate", "beat", "--replace")
+	h.waitFor("replaced worker to exit", func() bool { return !alive(first.PID) })
+	if replaced := h.meta("beat"); replaced.PID == first.PID || !alive(replaced.PID) {
+		t.Errorf("--replace did not restart the probe: %+v", replaced)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestDryRun(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo 'dry run'"
+duration = "1h"
+iterations = 7
+group = "e2e"
+`)
+
+	out := h.run("hibernate", "beat", "--dry-run")
+	for _, want := range []string{"beat (workflow beat)", "hibernate-worker --probe beat", "--script 'echo '\"'\"'dry run'\"'\"''", "… 2 more, every 1h0m0s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output lacks %q:\n%s", want, out)
+		}
+	}
+	if h.hasMeta("beat") {
+		t.Fatal("dry run launched the probe")
+	}
+
+	// under a time scale the fire times are the wall times the worker will keep
+	out = h.run("hibernate", "beat", "--dry-run", "--time-scale", "3600")
+	for _, want := range []string{"(in 1s)", "… 2 more, every 1s", "every 1h0m0s of schedule takes 1s of wall time"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scaled dry run output lacks %q:\n%s", want, out)
+		}
+	}
+
+	if out := h.run("hibernate", "--group", "e2e", "--dry-run"); !strings.Contains(out, "group e2e: 1 would spawn") || h.hasMeta("beat") {
+		t.Fatalf("group dry run launched or misreported:\n%s", out)
+	}
+
+	h.run("hibernate", "beat")
+	meta := h.meta("beat")
+	out = h.run("cryostasis", "--all", "--dry-run")
+	for _, want := range []string{fmt.Sprintf("PID %d for", meta.PID), "would remove metadata", "would remove log"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cryostasis dry run lacks %q:\n%s", want, out)
+		}
+	}
+	if !alive(meta.PID) || !h.hasMeta("beat") {
+		t.Error("cryostasis dry run stopped the probe")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestTrigger(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo pulse"
+duration = "1h"
+iterations = 1
+`)
+
+	h.run("hibernate", "beat")
+	meta := h.meta("beat")
+
+	// a trigger right after launch must not kill a worker still starting up
+	if out := h.run("trigger", "beat"); !strings.Contains(out, "schedule kept") {
+		t.Errorf("trigger output:\n%s", out)
+	}
+	h.waitLog("beat", "manual trigger, schedule kept", 1)
+	h.waitLog("beat", "pulse", 1)
+
+	h.run("trigger", "beat", "--reset")
+	h.waitLog("beat", "manual trigger, countdown reset", 1)
+	h.waitLog("beat", "pulse", 2)
+	if !alive(meta.PID) {
+		t.Fatal("worker exited after manual triggers")
+	}
+	if runs := strings.Count(h.log("beat"), "fully complete"); runs != 0 {
+		t.Error("manual triggers counted towards the iterations")
+	}
+
+	if out := h.fail("trigger", "missing"); !strings.Contains(out, "probe missing not found") {
+		t.Errorf("triggering an unknown probe:\n%s", out)
+	}
+}
+
+func TestSnoozeExtend(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo pulse"
+duration = "1h"
+iterations = 1
+`)
+
+	h.run("hibernate", "beat")
+	launched := h.meta("beat").NextFire
+
+	h.run("snooze", "beat", "10m")
+	h.waitLog("beat", "next firing moved", 1)
+	snoozed := h.meta("beat").NextFire
+	if d := time.Until(snoozed); d < 9*time.Minute || d > 10*time.Minute || !snoozed.Before(launched) {
+		t.Errorf("snoozed to %s, launched due %s", snoozed, launched)
+	}
+	if out := h.run("scan"); !strings.Contains(out, "next ") {
+		t.Errorf("scan does not show the next firing:\n%s", out)
+	}
+
+	h.run("extend", "beat", "+15m")
+	h.waitLog("beat", "next firing moved", 2)
+	if got := h.meta("beat").NextFire; !got.Equal(snoozed.Add(15 * time.Minute)) {
+		t.Errorf("extended to %s, want %s", got, snoozed.Add(15*time.Minute))
+	}
+
+	// pulled into the past, the one-shot fires at once and finishes
+	h.run("extend", "beat", "-1h")
+	h.waitLog("beat", "fully complete", 1)
+	if !strings.Contains(h.log("beat"), "pulse") {
+		t.Error("extended probe never ran its script")
+	}
+
+	if out := h.fail("snooze", "beat", "5m"); !strings.Contains(out, "not running") {
+		t.Errorf("snoozing a finished probe:\n%s", out)
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo pulse"
+duration = "1h"
+recurrent = true
+`)
+
+	h.run("hibernate", "beat")
+	meta := h.meta("beat")
+	sock := filepath.Join(h.home, "run", "beat.sock")
+	h.waitFor("control socket", func() bool {
+		_, err := os.Stat(sock)
+		return err == nil
+	})
+
+	if out := h.run("stasis", "beat"); !strings.Contains(out, "paused with") {
+		t.Errorf("stasis output:\n%s", out)
+	}
+	if out := h.run("scan"); !strings.Contains(out, "left") {
+		t.Errorf("scan does not show the paused countdown:\n%s", out)
+	}
+	if out := h.run("stasis", "beat", "--resume"); !strings.Contains(out, "resumed, next firing at") {
+		t.Errorf("resume output:\n%s", out)
+	}
+	h.waitLog("beat", "▸ resumed", 1)
+
+	// without its socket the worker still answers signals
+	if err := os.Remove(sock); err != nil {
+		t.Fatal(err)
+	}
+	h.run("trigger", "beat")
+	h.waitLog("beat", "pulse", 1)
+
+	h.run("snooze", "beat", "5m")
+	h.waitLog("beat", "next firing moved", 1)
+
+	// freezing the process would not stop its clock, so stasis needs the socket
+	if out := h.fail("stasis", "beat"); !strings.Contains(out, "no control socket") {
+		t.Errorf("stasis without a socket:\n%s", out)
+	}
+	if err := syscall.Kill(meta.PID, syscall.SIGSTOP); err != nil {
+		t.Fatal(err)
+	}
+	if out := h.run("stasis", "beat", "--resume"); !strings.Contains(out, "sent SIGCONT") {
+		t.Errorf("resume of a stopped worker:\n%s", out)
+	}
+
+	if out := h.run("cryostasis", "beat"); !strings.Contains(out, "sent SIGTERM") {
+		t.Errorf("cryostasis without a socket:\n%s", out)
+	}
+	h.waitFor("worker to exit", func() bool { return !alive(meta.PID) })
+}
+
+func TestCryostasisOverSocket(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo pulse; sleep 1"
+duration = "100ms"
+recurrent = true
+`)
+
+	h.run("hibernate", "beat")
+	meta := h.meta("beat")
+	sock := filepath.Join(h.home, "run", "beat.sock")
+	h.waitLog("beat", "pulse", 1)
+
+	// stopped mid-run, the worker is gone and its log with it by the time cryostasis returns
+	if out := h.run("cryostasis", "beat"); !strings.Contains(out, "acknowledged stop") {
+		t.Errorf("cryostasis output:\n%s", out)
+	}
+	if alive(meta.PID) {
+		t.Error("cryostasis returned before the worker exited")
+	}
+	time.Sleep(time.Second)
+	if _, err := os.Stat(meta.LogPath); !os.IsNotExist(err) {
+		t.Errorf("log left behind: %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket left behind: %v", err)
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.beat]
+script = "echo pulse"
+duration = "1h"
+recurrent = true
+
+[workflows.once]
+script = "echo once"
+duration = "1s"
+on_success = "after"
+
+[workflows.after]
+script = "echo after"
+duration = "1s"
+`)
+
+	if out := h.run("daemon", "status"); !strings.Contains(out, "no daemon running") {
+		t.Fatalf("status before start:\n%s", out)
+	}
+	if out, err := h.execIn(t.TempDir(), []string{"ORIGIN=daemon"}, "daemon", "start"); err != nil {
+		t.Fatalf("daemon start: %v\n%s", err, out)
+	}
+	h.fail("daemon", "start")
+
+	// scripts start from the launching shell, as they would in a worker process
+	caller := t.TempDir()
+	h.write("config/where.toml", `
+[workflows.where]
+script = "echo origin=$ORIGIN dir=$(pwd)"
+duration = "1s"
+`)
+	if out, err := h.execIn(caller, []string{"ORIGIN=caller"}, "hibernate", "where"); err != nil {
+		t.Fatalf("hibernate where: %v\n%s", err, out)
+	}
+	h.waitLog("where", "origin=caller dir="+caller, 1)
+
+	if out := h.run("hibernate", "beat"); !strings.Contains(out, "in daemon PID") {
+		t.Fatalf("probe not handed to the daemon:\n%s", out)
+	}
+	h.run("hibernate", "once")
+	beat := h.meta("beat")
+	if !beat.Daemon {
+		t.Fatal("metadata does not record the daemon")
+	}
+
+	// script output lands in the probe log, and chains run inside the daemon too
+	h.waitLog("once", "once", 1)
+	h.waitFor("chained probe", func() bool { return h.hasMeta("after") })
+	h.waitLog("after", "fully complete", 1)
+	if after := h.meta("after"); !after.Daemon {
+		t.Error("chained probe left the daemon")
+	}
+	h.waitFor("finished probes released", func() bool { return h.meta("once").PID == 0 && h.meta("after").PID == 0 })
+	for _, line := range strings.Split(h.run("scan"), "\n") {
+		if strings.HasPrefix(line, "once ") && !strings.Contains(line, "mortem") {
+			t.Errorf("released probe not shown as ended: %s", line)
+		}
+	}
+
+	h.run("trigger", "beat")
+	h.waitLog("beat", "pulse", 1)
+	if out := h.run("daemon", "status"); !strings.Contains(out, "1 probe(s): beat") {
+		t.Errorf("daemon status:\n%s", out)
+	}
+
+	if out := h.run("cryostasis", "beat"); !strings.Contains(out, "acknowledged stop") {
+		t.Errorf("cryostasis in the daemon:\n%s", out)
+	}
+	if !alive(beat.PID) {
+		t.Fatal("cryostasis of one probe took the daemon down")
+	}
+
+	h.run("hibernate", "beat")
+	h.run("daemon", "stop")
+	if alive(beat.PID) {
+		t.Error("daemon still running after stop")
+	}
+	if out := h.run("scan"); !strings.Contains(out, "mortem") {
+		t.Errorf("probes of a stopped daemon:\n%s", out)
+	}
+
+	// without a daemon, launches fall back to worker processes
+	if out := h.run("hibernate", "beat", "--replace"); strings.Contains(out, "daemon") {
+		t.Errorf("launch after the daemon stopped:\n%s", out)
+	}
+}
+
+func TestDaemonStopTimeout(t *testing.T) {
+	h := newHarness(t)
+	h.config(`
+[workflows.slow]
+script = "echo begin; sleep 3"
+duration = "100ms"
+`)
+
+	h.run("daemon", "start")
+	h.run("hibernate", "slow")
+	h.waitLog("slow", "begin", 1)
+
+	// the run in progress outlasts the timeout, so stop gives up and the daemon stays
+	if out := h.fail("daemon", "stop", "--timeout", "200ms"); !strings.Contains(out, "still shutting down") {
+		t.Errorf("daemon stop past its timeout:\n%s", out)
+	}
+	if out := h.run("daemon", "stop"); !strings.Contains(out, "stopped") {
+		t.Errorf("daemon stop:\n%s", out)
+	}
+}
+
+func TestScriptsKeepDefaultSignals(t *testing.T) {
+	if _, err := os.Stat("/proc/self/status"); err != nil {
+		t.Skipf("no /proc: %v", err)
+	}
+	h := newHarness(t)
+	h.config(`
+[workflows.mask]
+script = "grep SigIgn /proc/$$/status"
+duration = "100ms"
+`)
+
+	// SIGHUP, SIGUSR1 and SIGUSR2 are bits 0, 9 and 11 of the ignored mask
+	ignored := func(label string) {
+		t.Helper()
+		for _, line := range strings.Split(h.log("mask"), "\n") {
+			_, hex, ok := strings.Cut(line, "SigIgn:")
+			if !ok {
+				continue
+			}
+			mask, err := strconv.ParseUint(strings.TrimSpace(hex), 16, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mask&(1<<0|1<<9|1<<11) != 0 {
+				t.Errorf("%s script ignores control signals: %s", label, line)
+			}
+		}
+	}
+
+	h.run("hibernate", "mask")
+	h.waitLog("mask", "fully complete", 1)
+	ignored("worker")
+
+	h.run("daemon", "start")
+	h.run("hibernate", "mask", "--replace")
+	h.waitLog("mask", "fully complete", 2)
+	ignored("daemon")
+	h.run("daemon", "stop")
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Clock is the engine's source of time, so schedules can run without waiting in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Canceler is a Clock that can drop a pending After nobody waits for anymore; cancelling a
+// delivered or unknown channel does nothing
+type Canceler interface {
+	Cancel(ch <-chan time.Time)
+}
+
+// CancelAfter drops a superseded After on clocks that support it
+func CancelAfter(c Clock, ch <-chan time.Time) {
+	if cc, ok := c.(Canceler); ok && ch != nil {
+		cc.Cancel(ch)
+	}
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time                         { return time.Now() }
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ScaledClock runs time faster by a constant factor, so a 1h schedule elapses in 1h/scale of
+// wall time; it exists for integration testing
+type ScaledClock struct {
+	scale  float64
+	origin time.Time
+}
+
+func NewScaledClock(scale float64) *ScaledClock {
+	return &ScaledClock{scale: scale, origin: time.Now()}
+}
+
+// Now is the scaled time since the clock was created
+func (c *ScaledClock) Now() time.Time {
+	return c.origin.Add(time.Duration(float64(time.Since(c.origin)) * c.scale))
+}
+
+func (c *ScaledClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	time.AfterFunc(time.Duration(float64(d)/c.scale), func() { ch <- c.Now() })
+	return ch
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
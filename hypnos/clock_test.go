@@ -0,0 +1,274 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var epoch = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// delivered reports whether ch has a value ready, without blocking
+func delivered(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-ch:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeClockAdvance(t *testing.T) {
+	c := NewFakeClock(epoch)
+	later := c.After(3 * time.Minute)
+	first := c.After(time.Minute)
+	second := c.After(2 * time.Minute)
+
+	c.Advance(90 * time.Second)
+	if at, ok := delivered(first); !ok || !at.Equal(epoch.Add(90*time.Second)) {
+		t.Fatalf("first waiter: delivered %v at %s", ok, at)
+	}
+	for _, ch := range []<-chan time.Time{second, later} {
+		if _, ok := delivered(ch); ok {
+			t.Fatal("waiter delivered before its deadline")
+		}
+	}
+	if n := c.Waiters(); n != 2 {
+		t.Errorf("%d waiters left, want 2", n)
+	}
+
+	c.Advance(30 * time.Second)
+	if _, ok := delivered(second); !ok {
+		t.Error("waiter not delivered exactly at its deadline")
+	}
+	c.Advance(time.Hour)
+	if _, ok := delivered(later); !ok {
+		t.Error("late waiter not delivered")
+	}
+	if got := c.Now(); !got.Equal(epoch.Add(time.Hour + 2*time.Minute)) {
+		t.Errorf("now %s", got)
+	}
+}
+
+func TestFakeClockImmediate(t *testing.T) {
+	c := NewFakeClock(epoch)
+	for _, d := range []time.Duration{0, -time.Second} {
+		if at, ok := delivered(c.After(d)); !ok || !at.Equal(epoch) {
+			t.Errorf("After(%s) not delivered at once", d)
+		}
+	}
+	if c.Waiters() != 0 {
+		t.Error("immediate waits were registered")
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	c := NewFakeClock(epoch)
+	fired := make(chan struct{})
+	go func() {
+		<-c.After(time.Second)
+		close(fired)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	waitOrFail(t, fired, "fake clock waiter")
+}
+
+func TestScaledClock(t *testing.T) {
+	c := NewScaledClock(360000)
+	start := c.Now()
+
+	select {
+	case <-c.After(time.Hour):
+	case <-time.After(5 * time.Second):
+		t.Fatal("a scaled hour took more than 5s")
+	}
+	if elapsed := c.Now().Sub(start); elapsed < time.Hour {
+		t.Errorf("scaled clock moved %s, want at least 1h", elapsed)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWorkerOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	fires := make(chan time.Time, 3)
+	runner := RunnerFunc(func() Result {
+		fires <- clock.Now()
+		return Result{}
+	})
+	w, _, _, _, _ := newTestWorker(Spec{Probe: "p", Duration: time.Hour, Recurrent: true, Iterations: 3}, runner)
+	w.Clock = clock
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(59 * time.Minute)
+		select {
+		case at := <-fires:
+			t.Fatalf("iteration %d fired early at %s", i, at)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.Advance(time.Minute)
+		select {
+		case at := <-fires:
+			if want := epoch.Add(time.Duration(i) * time.Hour); !at.Equal(want) {
+				t.Errorf("iteration %d fired at %s, want %s", i, at, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("iteration %d never fired", i)
+		}
+	}
+	waitOrFail(t, done, "worker to finish")
+}
+
+func TestRetryBackoffOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	attempts := make(chan time.Time, 3)
+	codes := exits(1, 1, 0)
+	runner := RunnerFunc(func() Result {
+		attempts <- clock.Now()
+		return codes()
+	})
+	spec := Spec{Probe: "p", Duration: time.Minute, Retries: 2, RetryBackoff: 10 * time.Second}
+	w, _, _, _, _ := newTestWorker(spec, runner)
+	w.Clock = clock
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+
+	// schedule, then the first and second backoff
+	want := []time.Time{epoch.Add(time.Minute), epoch.Add(time.Minute + 10*time.Second), epoch.Add(time.Minute + 30*time.Second)}
+	steps := []time.Duration{time.Minute, 10 * time.Second, 20 * time.Second}
+	for i, step := range steps {
+		clock.BlockUntil(1)
+		clock.Advance(step)
+		select {
+		case at := <-attempts:
+			if !at.Equal(want[i]) {
+				t.Errorf("attempt %d at %s, want %s", i+1, at, want[i])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attempt %d never ran", i+1)
+		}
+	}
+	waitOrFail(t, done, "worker to finish")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestHeapClock(t *testing.T) {
+	// an hour of clock time passes in 10ms
+	clock := NewHeapClock(360000)
+	if _, ok := delivered(clock.After(0)); !ok {
+		t.Fatal("non-positive duration did not deliver at once")
+	}
+
+	// registered out of order, delivered in deadline order from a single timer
+	spans := []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour}
+	chans := make([]<-chan time.Time, len(spans))
+	for i, d := range spans {
+		chans[i] = clock.After(d)
+	}
+	if n := clock.Pending(); n != 3 {
+		t.Fatalf("%d wakeups pending, want 3", n)
+	}
+
+	var at [3]time.Time
+	for i, ch := range chans {
+		select {
+		case at[i] = <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("wakeup after %s never delivered", spans[i])
+		}
+	}
+	if !at[1].Before(at[2]) || !at[2].Before(at[0]) {
+		t.Errorf("delivered out of deadline order: %v", at)
+	}
+	if n := clock.Pending(); n != 0 {
+		t.Errorf("%d wakeups left after delivery", n)
+	}
+}
+
+func TestHeapClockCancel(t *testing.T) {
+	clock := NewHeapClock(1)
+	first, second := clock.After(time.Hour), clock.After(2*time.Hour)
+
+	clock.Cancel(first)
+	clock.Cancel(first)
+	clock.Cancel(make(chan time.Time))
+	if n := clock.Pending(); n != 1 {
+		t.Fatalf("%d wakeups pending after cancelling one of two, want 1", n)
+	}
+	CancelAfter(clock, second)
+	CancelAfter(clock, nil)
+	if n := clock.Pending(); n != 0 {
+		t.Errorf("%d wakeups pending after cancelling both", n)
+	}
+
+	// cancelling an earlier wakeup leaves the later ones on time
+	fast := NewHeapClock(360000)
+	drop, keep := fast.After(time.Hour), fast.After(2*time.Hour)
+	fast.Cancel(drop)
+	select {
+	case <-keep:
+	case <-time.After(5 * time.Second):
+		t.Fatal("wakeup behind a cancelled one never delivered")
+	}
+	if _, ok := delivered(drop); ok {
+		t.Error("cancelled wakeup delivered")
+	}
+}
+
+func TestWorkersShareHeapClock(t *testing.T) {
+	clock := NewHeapClock(360000)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, probe := range []string{"a", "b", "c"} {
+		w, _, store, _, _ := newTestWorker(Spec{Probe: probe, Duration: time.Hour, Iterations: 2}, exits(0, 0))
+		w.Clock = clock
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run()
+			if n := len(store.records()); n != 2 {
+				t.Errorf("probe %s recorded %d runs, want 2", probe, n)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	waitOrFail(t, done, "workers to finish")
+}
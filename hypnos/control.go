@@ -0,0 +1,73 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Command is an instruction for a running worker, delivered on Worker.Control
+type Command interface {
+	command()
+}
+
+// Trigger asks a running worker to fire now; Reset restarts the countdown from the manual
+// firing instead of keeping the schedule
+type Trigger struct {
+	Reset bool
+}
+
+// Reschedule moves the next firing to At, postponing or advancing it without a restart, and
+// resumes a paused countdown; a time already past fires at once
+type Reschedule struct {
+	At time.Time
+}
+
+// Pause stops the countdown until Resume, which continues with the time that was left
+type Pause struct{}
+
+type Resume struct{}
+
+// Stop ends the schedule without firing again; runs in progress finish first
+type Stop struct{}
+
+// Status asks for the worker's State, sent on Reply without blocking, so Reply needs room for it
+type Status struct {
+	Reply chan<- State
+}
+
+func (Trigger) command()    {}
+func (Reschedule) command() {}
+func (Pause) command()      {}
+func (Resume) command()     {}
+func (Stop) command()       {}
+func (Status) command()     {}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// State is a running worker's schedule as of a Status command; Next is zero while paused
+type State struct {
+	Fired      int           `json:"fired"`
+	Next       time.Time     `json:"next,omitempty"`
+	Paused     bool          `json:"paused,omitempty"`
+	Remaining  time.Duration `json:"remaining"`
+	LastStatus string        `json:"last_status,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,150 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// HeapClock serves every After from one min-heap of deadlines behind a single timer, so any
+// number of workers sharing it cost one timer. A scale above 1 runs time faster, as ScaledClock.
+// Superseded wakeups are dropped with Cancel, so a long-lived clock holds only live ones
+type HeapClock struct {
+	mu     sync.Mutex
+	scale  float64
+	origin time.Time
+	queue  wakeups
+	byChan map[<-chan time.Time]*wakeup
+	timer  *time.Timer
+}
+
+func NewHeapClock(scale float64) *HeapClock {
+	return &HeapClock{scale: scale, origin: time.Now(), byChan: make(map[<-chan time.Time]*wakeup)}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Now is the scaled time since the clock was created
+func (c *HeapClock) Now() time.Time {
+	return c.origin.Add(time.Duration(float64(time.Since(c.origin)) * c.scale))
+}
+
+// After delivers once d has elapsed; non-positive durations deliver at once
+func (c *HeapClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	now := c.Now()
+	if d <= 0 {
+		ch <- now
+		return ch
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &wakeup{at: now.Add(d), ch: ch}
+	heap.Push(&c.queue, w)
+	c.byChan[ch] = w
+	c.arm(now)
+	return ch
+}
+
+// Cancel removes a wakeup that has not been delivered yet
+func (c *HeapClock) Cancel(ch <-chan time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w, ok := c.byChan[ch]
+	if !ok {
+		return
+	}
+	heap.Remove(&c.queue, w.index)
+	delete(c.byChan, ch)
+	c.arm(c.Now())
+}
+
+// Pending is how many wakeups are waiting on the heap
+func (c *HeapClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.Len()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// arm points the timer at the earliest wakeup, or stops it when none is left; c.mu must be held
+func (c *HeapClock) arm(now time.Time) {
+	if c.queue.Len() == 0 {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		return
+	}
+	wait := time.Duration(float64(c.queue[0].at.Sub(now)) / c.scale)
+	if c.timer == nil {
+		c.timer = time.AfterFunc(wait, c.fire)
+		return
+	}
+	c.timer.Reset(wait)
+}
+
+// fire delivers every wakeup that is due and re-arms for the next one
+func (c *HeapClock) fire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.Now()
+	for c.queue.Len() > 0 && !c.queue[0].at.After(now) {
+		w := heap.Pop(&c.queue).(*wakeup)
+		delete(c.byChan, w.ch)
+		w.ch <- now
+	}
+	c.arm(now)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type wakeup struct {
+	at    time.Time
+	ch    chan time.Time
+	index int
+}
+
+// wakeups is a container/heap ordered by deadline, keeping each wakeup's index for Cancel
+type wakeups []*wakeup
+
+func (q wakeups) Len() int           { return len(q) }
+func (q wakeups) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q wakeups) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *wakeups) Push(x any) {
+	w := x.(*wakeup)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+func (q *wakeups) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return w
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,176 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stepClock jumps forward by every duration it is asked to wait, so schedules complete instantly
+// while Now still reflects the time that would have passed
+type stepClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newStepClock() *stepClock {
+	return &stepClock{now: epoch}
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *stepClock) waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// memStore keeps run records in memory, calling onAppend for each one when set
+type memStore struct {
+	mu       sync.Mutex
+	runs     []RunRecord
+	err      error
+	onAppend func(RunRecord)
+}
+
+func (s *memStore) AppendRun(rec RunRecord) error {
+	s.mu.Lock()
+	s.runs = append(s.runs, rec)
+	hook := s.onAppend
+	s.mu.Unlock()
+	if hook != nil {
+		hook(rec)
+	}
+	return s.err
+}
+
+func (s *memStore) records() []RunRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RunRecord(nil), s.runs...)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// note is one delivered notification
+type note struct {
+	title string
+	msg   string
+}
+
+type memNotifier struct {
+	mu    sync.Mutex
+	notes []note
+	err   error
+}
+
+func (n *memNotifier) Notify(title, msg string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notes = append(n.notes, note{title, msg})
+	return n.err
+}
+
+func (n *memNotifier) sent() []note {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]note(nil), n.notes...)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// memLog collects worker log lines
+type memLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *memLog) logf(format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, a...))
+}
+
+func (l *memLog) contains(sub string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// exits returns a runner that exits with each code in turn, repeating the last one
+func exits(codes ...int) RunnerFunc {
+	var mu sync.Mutex
+	i := 0
+	return func() Result {
+		mu.Lock()
+		defer mu.Unlock()
+		code := codes[min(i, len(codes)-1)]
+		i++
+		res := Result{ExitCode: code, LastLine: fmt.Sprintf("exit %d", code)}
+		if code != 0 {
+			res.Err = fmt.Errorf("exit status %d", code)
+		}
+		return res
+	}
+}
+
+// newTestWorker wires a worker to in-memory dependencies
+func newTestWorker(spec Spec, runner Runner) (*Worker, *stepClock, *memStore, *memNotifier, *memLog) {
+	clock, store, notifier, log := newStepClock(), &memStore{}, &memNotifier{}, &memLog{}
+	w := &Worker{
+		Spec:     spec,
+		Clock:    clock,
+		Runner:   runner,
+		Notifier: notifier,
+		Store:    store,
+		Log:      log.logf,
+	}
+	return w, clock, store, notifier, log
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,138 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Notifier delivers a notification once a probe fires
+type Notifier interface {
+	Notify(title, msg string) error
+}
+
+// NotifierFunc adapts a function to Notifier
+type NotifierFunc func(title, msg string) error
+
+func (f NotifierFunc) Notify(title, msg string) error { return f(title, msg) }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// notification policies selected by `notify_on`
+const (
+	NotifyAlways  = "always"
+	NotifyFailure = "failure"
+	NotifySuccess = "success"
+	NotifyChange  = "change"
+)
+
+const (
+	DefaultNotifyTitle   = "Hypnos-{{.Probe}}"
+	DefaultNotifyMessage = "{{.Summary}}"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func ValidateNotifyOn(policy string) error {
+	switch policy {
+	case "", NotifyAlways, NotifyFailure, NotifySuccess, NotifyChange:
+		return nil
+	}
+	return fmt.Errorf("unknown notify_on policy %q (expected always, failure, success or change)", policy)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ValidateNotifyTemplate parses a title or message template so mistakes surface at launch
+func ValidateNotifyTemplate(name, text string) error {
+	if text == "" {
+		return nil
+	}
+	if _, err := template.New(name).Option("missingkey=error").Parse(text); err != nil {
+		return fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Tracker remembers the previous iteration status so `notify_on = "change"` can compare
+// the first iteration is compared against success, so a healthy probe starts quiet
+type Tracker struct {
+	mu   sync.Mutex
+	last string
+}
+
+// ShouldNotify records status and reports whether policy asks for a notification
+func (t *Tracker) ShouldNotify(policy, status string) bool {
+	t.mu.Lock()
+	prev := t.last
+	if prev == "" {
+		prev = StatusSuccess
+	}
+	t.last = status
+	t.mu.Unlock()
+
+	switch policy {
+	case NotifyFailure:
+		return status != StatusSuccess
+	case NotifySuccess:
+		return status == StatusSuccess
+	case NotifyChange:
+		return status != prev
+	default:
+		return true
+	}
+}
+
+// LastStatus reports the status of the most recent iteration, success if none ran a script
+func (t *Tracker) LastStatus() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == "" {
+		return StatusSuccess
+	}
+	return t.last
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RenderNotify expands a notification template over probe, group, iteration, status,
+// exit code, attempts, last output line and the default summary
+func RenderNotify(text, fallback string, data map[string]any) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("notify").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,103 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestTracker(t *testing.T) {
+	tests := []struct {
+		policy   string
+		statuses []string
+		want     []bool
+	}{
+		{NotifyAlways, []string{StatusSuccess, StatusFailure}, []bool{true, true}},
+		{NotifyFailure, []string{StatusSuccess, StatusFailure, StatusTimeout}, []bool{false, true, true}},
+		{NotifySuccess, []string{StatusSuccess, StatusFailure}, []bool{true, false}},
+		{NotifyChange, []string{StatusSuccess, StatusFailure, StatusFailure, StatusSuccess}, []bool{false, true, false, true}},
+		{NotifyChange, []string{StatusTimeout, StatusFailure}, []bool{true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var tr Tracker
+			for i, status := range tt.statuses {
+				if got := tr.ShouldNotify(tt.policy, status); got != tt.want[i] {
+					t.Errorf("run %d (%s): notify %v, want %v", i+1, status, got, tt.want[i])
+				}
+			}
+			if got, want := tr.LastStatus(), tt.statuses[len(tt.statuses)-1]; got != want {
+				t.Errorf("last status %q, want %q", got, want)
+			}
+		})
+	}
+
+	var fresh Tracker
+	if got := fresh.LastStatus(); got != StatusSuccess {
+		t.Errorf("fresh tracker reports %q", got)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestValidateNotify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		ok   bool
+	}{
+		{"empty policy", ValidateNotifyOn(""), true},
+		{"change policy", ValidateNotifyOn(NotifyChange), true},
+		{"unknown policy", ValidateNotifyOn("sometimes"), false},
+		{"empty template", ValidateNotifyTemplate("notify_title", ""), true},
+		{"valid template", ValidateNotifyTemplate("notify_title", "{{.Probe}}"), true},
+		{"broken template", ValidateNotifyTemplate("notify_title", "{{.Probe"), false},
+	}
+
+	for _, tt := range tests {
+		if (tt.err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, tt.err)
+		}
+	}
+}
+
+func TestRenderNotify(t *testing.T) {
+	data := map[string]any{"Probe": "p", "Status": StatusFailure}
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"", "Hypnos-p", true},
+		{"{{.Probe}} {{.Status}}", "p failure", true},
+		{"{{.Missing}}", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderNotify(tt.text, DefaultNotifyTitle, data)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("RenderNotify(%q) = %q, %v", tt.text, got, err)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,111 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// run statuses, as recorded in history and exposed to notification templates
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusTimeout = "timeout"
+	StatusSkipped = "skipped"
+)
+
+// RunRecord is one run of a probe, as kept in its history by the probe store
+type RunRecord struct {
+	Probe     string        `json:"probe"`
+	Iteration int           `json:"iteration"`
+	Attempt   int           `json:"attempt,omitempty"`
+	Started   time.Time     `json:"started"`
+	Finished  time.Time     `json:"finished"`
+	Elapsed   time.Duration `json:"elapsed"`
+	ExitCode  int           `json:"exit_code"`
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	LastLine  string        `json:"last_line,omitempty"`
+}
+
+// Result describes one execution of a probe's script
+type Result struct {
+	Started  time.Time
+	Finished time.Time
+	ExitCode int
+	TimedOut bool
+	LastLine string
+	Err      error
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRunRecord classifies a script result; exit codes listed in successCodes count as success (default 0)
+func NewRunRecord(probe string, iteration int, res Result, successCodes []int) RunRecord {
+	rec := RunRecord{
+		Probe:     probe,
+		Iteration: iteration,
+		Started:   res.Started,
+		Finished:  res.Finished,
+		Elapsed:   res.Finished.Sub(res.Started),
+		ExitCode:  res.ExitCode,
+		Status:    StatusSuccess,
+		LastLine:  res.LastLine,
+	}
+	switch {
+	case res.TimedOut:
+		rec.Status = StatusTimeout
+	case res.ExitCode < 0 || !IsSuccessCode(res.ExitCode, successCodes):
+		rec.Status = StatusFailure
+	}
+	if res.Err != nil && rec.Status != StatusSuccess {
+		rec.Error = res.Err.Error()
+	}
+	return rec
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func IsSuccessCode(code int, successCodes []int) bool {
+	if len(successCodes) == 0 {
+		return code == 0
+	}
+	for _, c := range successCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SkippedRunRecord records an iteration dropped by the skip overlap policy
+func SkippedRunRecord(probe string, iteration int, now time.Time) RunRecord {
+	return RunRecord{
+		Probe:     probe,
+		Iteration: iteration,
+		Started:   now,
+		Finished:  now,
+		ExitCode:  -1,
+		Status:    StatusSkipped,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,71 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestNewRunRecord(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		res    Result
+		codes  []int
+		status string
+		err    string
+	}{
+		{"exit zero", Result{ExitCode: 0}, nil, StatusSuccess, ""},
+		{"exit non-zero", Result{ExitCode: 1, Err: errors.New("exit status 1")}, nil, StatusFailure, "exit status 1"},
+		{"listed code", Result{ExitCode: 3, Err: errors.New("exit status 3")}, []int{0, 3}, StatusSuccess, ""},
+		{"zero not listed", Result{ExitCode: 0}, []int{3}, StatusFailure, ""},
+		{"never started", Result{ExitCode: -1, Err: errors.New("not found")}, []int{-1}, StatusFailure, "not found"},
+		{"timeout", Result{ExitCode: -1, TimedOut: true, Err: errors.New("timed out")}, nil, StatusTimeout, "timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.res.Started, tt.res.Finished = start, start.Add(3*time.Second)
+			rec := NewRunRecord("p", 2, tt.res, tt.codes)
+			if rec.Status != tt.status {
+				t.Errorf("status %q, want %q", rec.Status, tt.status)
+			}
+			if rec.Error != tt.err {
+				t.Errorf("error %q, want %q", rec.Error, tt.err)
+			}
+			if rec.Elapsed != 3*time.Second || rec.Probe != "p" || rec.Iteration != 2 {
+				t.Errorf("unexpected record %+v", rec)
+			}
+		})
+	}
+}
+
+func TestSkippedRunRecord(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	rec := SkippedRunRecord("p", 4, now)
+	if rec.Status != StatusSkipped || rec.ExitCode != -1 || !rec.Started.Equal(now) || rec.Iteration != 4 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,144 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Scheduler counts down to each firing of a probe: once, a fixed number of iterations, or
+// forever when recurrent without a limit
+type Scheduler struct {
+	clock      Clock
+	every      time.Duration
+	recurrent  bool
+	iterations int
+	fired      int
+	deadline   time.Time
+	paused     bool
+	left       time.Duration
+}
+
+// NewScheduler starts the countdown to the first firing
+func NewScheduler(clock Clock, every time.Duration, recurrent bool, iterations int) *Scheduler {
+	return &Scheduler{
+		clock:      clock,
+		every:      every,
+		recurrent:  recurrent,
+		iterations: iterations,
+		deadline:   clock.Now().Add(every),
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Deadline is when the next firing is due
+func (s *Scheduler) Deadline() time.Time {
+	return s.deadline
+}
+
+// Fired is how many times the probe has fired so far
+func (s *Scheduler) Fired() int {
+	return s.fired
+}
+
+// Done reports whether the last iteration has fired
+func (s *Scheduler) Done() bool {
+	if s.iterations > 0 {
+		return s.fired >= s.iterations
+	}
+	return !s.recurrent && s.fired > 0
+}
+
+// Paused reports whether the countdown is stopped
+func (s *Scheduler) Paused() bool {
+	return s.paused
+}
+
+// Remaining is the time left until the next firing, frozen while paused
+func (s *Scheduler) Remaining() time.Duration {
+	if s.paused {
+		return s.left
+	}
+	return s.deadline.Sub(s.clock.Now())
+}
+
+// Wait delivers once the deadline has passed; while paused it never delivers
+func (s *Scheduler) Wait() <-chan time.Time {
+	if s.paused {
+		return nil
+	}
+	return s.clock.After(s.deadline.Sub(s.clock.Now()))
+}
+
+// Upcoming lists the next n firings, fewer when the schedule ends first
+func (s *Scheduler) Upcoming(n int) []time.Time {
+	left := n
+	switch {
+	case s.iterations > 0:
+		left = min(n, s.iterations-s.fired)
+	case !s.recurrent:
+		left = min(n, 1-s.fired)
+	}
+
+	times := make([]time.Time, 0, max(left, 0))
+	for i := 0; i < left; i++ {
+		times = append(times, s.deadline.Add(time.Duration(i)*s.every))
+	}
+	return times
+}
+
+// Fire records a firing, restarts the countdown and returns the iteration number
+func (s *Scheduler) Fire() int {
+	s.fired++
+	s.deadline = s.clock.Now().Add(s.every)
+	return s.fired
+}
+
+// Trigger accounts for a manual firing, which does not count towards the iterations; reset
+// restarts the countdown from now, otherwise the schedule is kept. It returns the number of
+// scheduled firings so far, which labels the manual run
+func (s *Scheduler) Trigger(reset bool) int {
+	if reset {
+		s.deadline, s.left = s.clock.Now().Add(s.every), s.every
+	}
+	return s.fired
+}
+
+// SetDeadline moves the next firing, leaving the iteration count as it is, and resumes a
+// paused countdown
+func (s *Scheduler) SetDeadline(at time.Time) {
+	s.deadline, s.paused = at, false
+}
+
+// Pause stops the countdown, keeping the time left until the next firing
+func (s *Scheduler) Pause() {
+	if !s.paused {
+		s.paused, s.left = true, s.deadline.Sub(s.clock.Now())
+	}
+}
+
+// Resume restarts the countdown with the time that was left when it paused
+func (s *Scheduler) Resume() {
+	if s.paused {
+		s.paused, s.deadline = false, s.clock.Now().Add(s.left)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,201 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSchedulerFires(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrent  bool
+		iterations int
+		want       int // fires before Done, -1 for never done
+	}{
+		{"once", false, 0, 1},
+		{"fixed iterations", false, 3, 3},
+		{"iterations bound a recurrent probe", true, 4, 4},
+		{"recurrent forever", true, 0, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newStepClock()
+			s := NewScheduler(clock, time.Minute, tt.recurrent, tt.iterations)
+
+			const limit = 50
+			for s.Fired() < limit && !s.Done() {
+				<-s.Wait()
+				s.Fire()
+			}
+
+			got := s.Fired()
+			if tt.want < 0 {
+				if got != limit {
+					t.Fatalf("recurrent schedule stopped after %d fires", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("fired %d times, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerDeadline(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewScheduler(clock, 25*time.Minute, true, 0)
+
+	if got, want := s.Deadline(), epoch.Add(25*time.Minute); !got.Equal(want) {
+		t.Fatalf("first deadline %s, want %s", got, want)
+	}
+
+	wait := s.Wait()
+	clock.Advance(24 * time.Minute)
+	if _, ok := delivered(wait); ok {
+		t.Fatal("schedule fired before its deadline")
+	}
+	clock.Advance(time.Minute)
+	if _, ok := delivered(wait); !ok {
+		t.Fatal("schedule did not fire at its deadline")
+	}
+
+	if n := s.Fire(); n != 1 {
+		t.Fatalf("first fire is iteration %d", n)
+	}
+	if got, want := s.Deadline(), epoch.Add(50*time.Minute); !got.Equal(want) {
+		t.Errorf("second deadline %s, want %s", got, want)
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewScheduler(clock, time.Hour, true, 2)
+	clock.Advance(20 * time.Minute)
+
+	if n := s.Trigger(false); n != 0 {
+		t.Errorf("manual firing before any scheduled one labelled %d", n)
+	}
+	if got, want := s.Deadline(), epoch.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("kept deadline %s, want %s", got, want)
+	}
+
+	s.Trigger(true)
+	if got, want := s.Deadline(), epoch.Add(80*time.Minute); !got.Equal(want) {
+		t.Errorf("reset deadline %s, want %s", got, want)
+	}
+	if s.Fired() != 0 || s.Done() {
+		t.Errorf("manual firings counted towards the iterations: fired %d", s.Fired())
+	}
+}
+
+func TestSchedulerSetDeadline(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewScheduler(clock, time.Hour, false, 0)
+
+	s.SetDeadline(epoch.Add(75 * time.Minute))
+	wait := s.Wait()
+	clock.Advance(time.Hour)
+	if _, ok := delivered(wait); ok {
+		t.Fatal("postponed schedule fired at its original deadline")
+	}
+	clock.Advance(15 * time.Minute)
+	if _, ok := delivered(wait); !ok {
+		t.Fatal("postponed schedule did not fire at its new deadline")
+	}
+	if s.Fired() != 0 {
+		t.Errorf("moving the deadline counted as a firing")
+	}
+}
+
+func TestSchedulerPause(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewScheduler(clock, time.Hour, true, 0)
+
+	clock.Advance(20 * time.Minute)
+	s.Pause()
+	if s.Wait() != nil {
+		t.Fatal("paused schedule still counts down")
+	}
+	clock.Advance(2 * time.Hour)
+	if !s.Paused() || s.Remaining() != 40*time.Minute {
+		t.Fatalf("paused %v with %s left, want 40m", s.Paused(), s.Remaining())
+	}
+
+	s.Resume()
+	if got, want := s.Deadline(), epoch.Add(180*time.Minute); s.Paused() || !got.Equal(want) {
+		t.Errorf("resumed deadline %s, want %s", got, want)
+	}
+
+	s.Pause()
+	s.SetDeadline(epoch.Add(3 * time.Hour))
+	if s.Paused() {
+		t.Error("moving the deadline left the schedule paused")
+	}
+}
+
+func TestSchedulerUpcoming(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrent  bool
+		iterations int
+		fired      int
+		want       int
+	}{
+		{"once", false, 0, 0, 1},
+		{"once, already fired", false, 0, 1, 0},
+		{"iterations below the limit", false, 3, 0, 3},
+		{"iterations partly fired", true, 3, 2, 1},
+		{"recurrent is cut at the limit", true, 0, 0, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(epoch)
+			s := NewScheduler(clock, time.Hour, tt.recurrent, tt.iterations)
+			for i := 0; i < tt.fired; i++ {
+				clock.Advance(time.Hour)
+				s.Fire()
+			}
+
+			got := s.Upcoming(5)
+			if len(got) != tt.want {
+				t.Fatalf("%d upcoming firings, want %d", len(got), tt.want)
+			}
+			for i, at := range got {
+				if want := epoch.Add(time.Duration(tt.fired+i+1) * time.Hour); !at.Equal(want) {
+					t.Errorf("firing %d at %s, want %s", i, at, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerNotDoneBeforeFiring(t *testing.T) {
+	if s := NewScheduler(newStepClock(), time.Second, false, 0); s.Done() {
+		t.Error("a fresh one-shot schedule reports done")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
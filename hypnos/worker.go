@@ -0,0 +1,427 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package hypnos is the engine behind a hibernating probe: it counts down a schedule, runs the
+// probe's script with retries, records every run and notifies, all through injected
+// dependencies so the command line is a thin wrapper around it
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Runner executes a probe's script once
+type Runner interface {
+	Run() Result
+}
+
+// RunnerFunc adapts a function to Runner
+type RunnerFunc func() Result
+
+func (f RunnerFunc) Run() Result { return f() }
+
+// Store keeps the history of every run
+type Store interface {
+	AppendRun(rec RunRecord) error
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// overlap policies for a timer that fires while the previous run is still active
+const (
+	OverlapSkip     = "skip"
+	OverlapQueue    = "queue"
+	OverlapParallel = "parallel"
+)
+
+// pending iterations buffered by the queue policy before the timer loop blocks
+const queueDepth = 16
+
+func ValidateOverlap(policy string) error {
+	switch policy {
+	case "", OverlapSkip, OverlapQueue, OverlapParallel:
+		return nil
+	}
+	return fmt.Errorf("unknown overlap policy %q (expected skip, queue or parallel)", policy)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Spec is what the engine needs to know about a probe
+type Spec struct {
+	Probe      string
+	Group      string
+	Step       string
+	Duration   time.Duration
+	Recurrent  bool
+	Iterations int
+	NotifyOnly bool
+	Overlap    string
+	Timeout    time.Duration
+
+	Retries         int
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+	SuccessCodes    []int
+
+	NotifyOn      string
+	NotifyTitle   string
+	NotifyMessage string
+	NotifyTimeout bool // timed-out runs notify only when set
+	Vars          map[string]string
+}
+
+// Worker runs one probe; the zero values of Clock and Log are the wall clock and no logging,
+// and a nil Control channel never delivers. OnDeadline, when set, hears of every change to the
+// next firing, and of the zero time once the schedule is done
+type Worker struct {
+	Spec       Spec
+	Clock      Clock
+	Runner     Runner
+	Notifier   Notifier
+	Store      Store
+	Log        func(format string, a ...any)
+	Control    <-chan Command
+	OnDeadline func(next time.Time)
+
+	tracker Tracker
+	stopped bool
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Run drives the schedule until the last iteration has fired and every run has finished,
+// returning the status of the last run
+func (w *Worker) Run() string {
+	w.logf("Downtime %q started for %s", w.Spec.Probe, w.Spec.Duration)
+	w.stopped = false
+
+	var (
+		wg      sync.WaitGroup
+		running atomic.Int32
+		queue   = make(chan int, queueDepth)
+		sched   = NewScheduler(w.clock(), w.Spec.Duration, w.Spec.Recurrent, w.Spec.Iterations)
+	)
+
+	// queue policy: a single runner drains fired iterations in order
+	go func() {
+		for n := range queue {
+			w.Fire(n)
+			wg.Done()
+		}
+	}()
+
+	dispatch := func(n int) {
+		switch w.Spec.Overlap {
+		case OverlapSkip:
+			if running.Load() > 0 {
+				w.logf("▸ iteration %d skipped, previous run still active", n)
+				w.record(SkippedRunRecord(w.Spec.Probe, n, w.clock().Now()))
+				return
+			}
+			wg.Add(1)
+			running.Add(1)
+			go func() {
+				defer wg.Done()
+				defer running.Add(-1)
+				w.Fire(n)
+			}()
+		case OverlapParallel:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Fire(n)
+			}()
+		default:
+			wg.Add(1)
+			queue <- n
+		}
+	}
+
+	// a replaced wait is cancelled, so a shared clock does not keep it until its old deadline
+	var wait <-chan time.Time
+	rearm := func() {
+		CancelAfter(w.clock(), wait)
+		wait = sched.Wait()
+		if sched.Paused() {
+			w.deadline(time.Time{})
+		} else {
+			w.deadline(sched.Deadline())
+		}
+	}
+
+	rearm()
+schedule:
+	for {
+		select {
+		case cmd := <-w.Control:
+			switch c := cmd.(type) {
+			case Trigger:
+				n := sched.Trigger(c.Reset)
+				if c.Reset {
+					rearm()
+					w.logf("▸ manual trigger, countdown reset; next firing in %s", sched.Remaining().Round(time.Second))
+				} else {
+					w.logf("▸ manual trigger, schedule kept; next firing in %s", sched.Remaining().Round(time.Second))
+				}
+				dispatch(n)
+			case Reschedule:
+				sched.SetDeadline(c.At)
+				rearm()
+				w.logf("▸ next firing moved to %s", sched.Deadline().Format(time.TimeOnly))
+			case Pause:
+				sched.Pause()
+				rearm()
+				w.logf("▸ paused with %s left", sched.Remaining().Round(time.Second))
+			case Resume:
+				sched.Resume()
+				rearm()
+				w.logf("▸ resumed; next firing at %s", sched.Deadline().Format(time.TimeOnly))
+			case Status:
+				select {
+				case c.Reply <- w.state(sched):
+				default:
+				}
+			case Stop:
+				w.stopped = true
+				break schedule
+			}
+			continue
+		case <-wait:
+		}
+
+		n := sched.Fire()
+		dispatch(n)
+
+		if sched.Done() {
+			break
+		}
+		rearm()
+		w.logf("▸ iteration %d fired, restarting timer", n)
+	}
+	CancelAfter(w.clock(), wait)
+	w.deadline(time.Time{})
+
+	close(queue)
+	wg.Wait()
+
+	if w.stopped {
+		w.logf("Downtime %q stopped (ran %d times)", w.Spec.Probe, sched.Fired())
+		return w.tracker.LastStatus()
+	}
+	w.logf("Downtime %q fully complete (ran %d times)", w.Spec.Probe, sched.Fired())
+	return w.tracker.LastStatus()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Fire runs one iteration: script with retries (unless notify-only), history records and,
+// depending on notify_on, a templated notification
+func (w *Worker) Fire(iteration int) RunRecord {
+	spec := w.Spec
+	rec := RunRecord{Probe: spec.Probe, Iteration: iteration, Status: StatusSuccess}
+	attempts := 0
+	summary := "Downtime complete"
+	if spec.Step != "" {
+		summary = spec.Step + " complete"
+	}
+
+	if !spec.NotifyOnly {
+		w.logf("▸ timer fired, executing shell snippet")
+		rec, attempts = w.runWithRetries(iteration)
+		switch rec.Status {
+		case StatusSuccess:
+			if attempts > 1 {
+				summary = fmt.Sprintf("Downtime complete after %d attempts", attempts)
+			}
+		case StatusTimeout:
+			summary = fmt.Sprintf("Downtime timed out after %s (%d attempts)", spec.Timeout, attempts)
+		default:
+			summary = fmt.Sprintf("Downtime failed after %d attempts (exit %d)", attempts, rec.ExitCode)
+		}
+	} else {
+		w.logf("▸ notify-only mode, skipping script execution")
+	}
+
+	if !w.tracker.ShouldNotify(spec.NotifyOn, rec.Status) {
+		w.logf("▸ notification suppressed (notify_on = %s, status %s)", spec.NotifyOn, rec.Status)
+		return rec
+	}
+	if rec.Status == StatusTimeout && !spec.NotifyTimeout {
+		w.logf("▸ timeout notification suppressed (notify_timeout not set)")
+		return rec
+	}
+
+	// workflow vars are visible to the templates; built-in fields win on a name clash
+	data := make(map[string]any, len(spec.Vars)+9)
+	for k, v := range spec.Vars {
+		data[k] = v
+	}
+	for k, v := range map[string]any{
+		"Probe":     spec.Probe,
+		"Group":     spec.Group,
+		"Iteration": iteration,
+		"Status":    rec.Status,
+		"ExitCode":  rec.ExitCode,
+		"Attempts":  attempts,
+		"LastLine":  rec.LastLine,
+		"Summary":   summary,
+		"Step":      spec.Step,
+	} {
+		data[k] = v
+	}
+	title, err := RenderNotify(spec.NotifyTitle, DefaultNotifyTitle, data)
+	if err != nil {
+		w.logf("▸ notify title template failed: %v", err)
+		title, _ = RenderNotify("", DefaultNotifyTitle, data)
+	}
+	msg, err := RenderNotify(spec.NotifyMessage, DefaultNotifyMessage, data)
+	if err != nil {
+		w.logf("▸ notify message template failed: %v", err)
+		msg = summary
+	}
+
+	w.logf("▸ timer fired, sending notification")
+	if w.Notifier == nil {
+		w.logf("▸ notify failed: no notifier")
+	} else if err := w.Notifier.Notify(title, msg); err != nil {
+		w.logf("▸ notify failed: %v", err)
+	} else {
+		w.logf("▸ notify succeeded")
+	}
+	return rec
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runWithRetries runs the script until it succeeds or retries are exhausted, recording every attempt
+func (w *Worker) runWithRetries(iteration int) (RunRecord, int) {
+	spec := w.Spec
+	var rec RunRecord
+	attempt := 0
+	for {
+		attempt++
+		if spec.Retries > 0 {
+			w.logf("▸ attempt %d/%d", attempt, spec.Retries+1)
+		}
+
+		rec = NewRunRecord(spec.Probe, iteration, w.Runner.Run(), spec.SuccessCodes)
+		rec.Attempt = attempt
+		w.record(rec)
+
+		switch rec.Status {
+		case StatusSuccess:
+			if rec.ExitCode != 0 {
+				w.logf("▸ command exited %d, accepted as success", rec.ExitCode)
+			}
+			if attempt > 1 {
+				w.logf("▸ command succeeded after %d attempts", attempt)
+			}
+			return rec, attempt
+		case StatusTimeout:
+			w.logf("▸ command timed out after %s, process group killed", spec.Timeout)
+		default:
+			w.logf("▸ command failed: %s", rec.Error)
+		}
+
+		if attempt > spec.Retries {
+			if spec.Retries > 0 {
+				w.logf("▸ giving up after %d attempts", attempt)
+			}
+			return rec, attempt
+		}
+
+		wait := RetryDelay(spec.RetryBackoff, spec.RetryBackoffMax, attempt)
+		w.logf("▸ retrying in %s", wait)
+		<-w.clock().After(wait)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RetryDelay doubles the backoff after every failed attempt, capped at max when set
+func RetryDelay(backoff, max time.Duration, attempt int) time.Duration {
+	wait := backoff
+	for i := 1; i < attempt && wait > 0; i++ {
+		wait *= 2
+		if max > 0 && wait >= max {
+			return max
+		}
+	}
+	if max > 0 && wait > max {
+		return max
+	}
+	return wait
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (w *Worker) clock() Clock {
+	if w.Clock == nil {
+		return SystemClock{}
+	}
+	return w.Clock
+}
+
+func (w *Worker) logf(format string, a ...any) {
+	if w.Log != nil {
+		w.Log(format, a...)
+	}
+}
+
+// Stopped reports whether the last Run ended on a Stop command rather than its schedule
+func (w *Worker) Stopped() bool {
+	return w.stopped
+}
+
+func (w *Worker) state(sched *Scheduler) State {
+	st := State{
+		Fired:      sched.Fired(),
+		Paused:     sched.Paused(),
+		Remaining:  sched.Remaining(),
+		LastStatus: w.tracker.LastStatus(),
+	}
+	if !st.Paused {
+		st.Next = sched.Deadline()
+	}
+	return st
+}
+
+func (w *Worker) deadline(next time.Time) {
+	if w.OnDeadline != nil {
+		w.OnDeadline(next)
+	}
+}
+
+// record appends to the history; a failing store is logged, never fatal to the worker
+func (w *Worker) record(rec RunRecord) {
+	if w.Store == nil {
+		return
+	}
+	if err := w.Store.AppendRun(rec); err != nil {
+		w.logf("▸ recording history failed: %v", err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
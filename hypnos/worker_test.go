@@ -0,0 +1,726 @@
+/*
+Copyright © 2026 Daniel Rivas <danielrivasmd@gmail.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package hypnos
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWorkerRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrent  bool
+		iterations int
+		codes      []int
+		runs       int
+		status     string
+	}{
+		{"one shot", false, 0, []int{0}, 1, StatusSuccess},
+		{"iterations", false, 3, []int{0}, 3, StatusSuccess},
+		{"recurrent with a limit", true, 2, []int{0}, 2, StatusSuccess},
+		{"last run decides the status", false, 2, []int{0, 1}, 2, StatusFailure},
+		{"recovery after a failure", false, 2, []int{1, 0}, 2, StatusSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := Spec{Probe: "p", Duration: time.Minute, Recurrent: tt.recurrent, Iterations: tt.iterations}
+			w, clock, store, notifier, log := newTestWorker(spec, exits(tt.codes...))
+
+			if got := w.Run(); got != tt.status {
+				t.Errorf("status %q, want %q", got, tt.status)
+			}
+			if got := len(store.records()); got != tt.runs {
+				t.Errorf("%d runs recorded, want %d", got, tt.runs)
+			}
+			if got := len(notifier.sent()); got != tt.runs {
+				t.Errorf("%d notifications, want %d", got, tt.runs)
+			}
+			if got := len(clock.waited()); got != tt.runs {
+				t.Errorf("waited %d times, want %d", got, tt.runs)
+			}
+			if !log.contains("fully complete") {
+				t.Error("completion was not logged")
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWorkerRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []int
+		retries  int
+		max      time.Duration
+		attempts int
+		waits    []time.Duration
+		status   string
+		msg      string
+	}{
+		{
+			name:     "succeeds after retrying",
+			codes:    []int{1, 1, 0},
+			retries:  3,
+			attempts: 3,
+			waits:    []time.Duration{time.Second, 2 * time.Second},
+			status:   StatusSuccess,
+			msg:      "Downtime complete after 3 attempts",
+		},
+		{
+			name:     "gives up",
+			codes:    []int{2},
+			retries:  2,
+			attempts: 3,
+			waits:    []time.Duration{time.Second, 2 * time.Second},
+			status:   StatusFailure,
+			msg:      "Downtime failed after 3 attempts (exit 2)",
+		},
+		{
+			name:     "backoff capped",
+			codes:    []int{1},
+			retries:  3,
+			max:      3 * time.Second,
+			attempts: 4,
+			waits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			status:   StatusFailure,
+			msg:      "Downtime failed after 4 attempts (exit 1)",
+		},
+		{
+			name:     "no retries",
+			codes:    []int{1},
+			attempts: 1,
+			waits:    []time.Duration{},
+			status:   StatusFailure,
+			msg:      "Downtime failed after 1 attempts (exit 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := Spec{Probe: "p", Duration: time.Minute, Retries: tt.retries, RetryBackoff: time.Second, RetryBackoffMax: tt.max}
+			w, clock, store, notifier, _ := newTestWorker(spec, exits(tt.codes...))
+
+			if got := w.Run(); got != tt.status {
+				t.Errorf("status %q, want %q", got, tt.status)
+			}
+
+			runs := store.records()
+			if len(runs) != tt.attempts {
+				t.Fatalf("%d attempts recorded, want %d", len(runs), tt.attempts)
+			}
+			for i, rec := range runs {
+				if rec.Attempt != i+1 || rec.Iteration != 1 {
+					t.Errorf("record %d is attempt %d of iteration %d", i, rec.Attempt, rec.Iteration)
+				}
+			}
+
+			// the first wait is the schedule itself, the rest are backoffs
+			if got := clock.waited()[1:]; !reflect.DeepEqual(got, tt.waits) {
+				t.Errorf("backoffs %v, want %v", got, tt.waits)
+			}
+			if sent := notifier.sent(); len(sent) != 1 || sent[0].msg != tt.msg {
+				t.Errorf("notifications %v, want message %q", sent, tt.msg)
+			}
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWorkerFireSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   Spec
+		result Result
+		status string
+		msg    string
+	}{
+		{
+			name:   "success",
+			result: Result{},
+			status: StatusSuccess,
+			msg:    "Downtime complete",
+		},
+		{
+			name:   "routine step",
+			spec:   Spec{Step: "break"},
+			result: Result{},
+			status: StatusSuccess,
+			msg:    "break complete",
+		},
+		{
+			name:   "timeout",
+			spec:   Spec{Timeout: 5 * time.Second, NotifyTimeout: true},
+			result: Result{ExitCode: -1, TimedOut: true, Err: errors.New("timed out after 5s")},
+			status: StatusTimeout,
+			msg:    "Downtime timed out after 5s (1 attempts)",
+		},
+		{
+			name:   "success code",
+			spec:   Spec{SuccessCodes: []int{0, 3}},
+			result: Result{ExitCode: 3},
+			status: StatusSuccess,
+			msg:    "Downtime complete",
+		},
+		{
+			name:   "unlisted exit code",
+			spec:   Spec{SuccessCodes: []int{3}},
+			result: Result{ExitCode: 0},
+			status: StatusFailure,
+			msg:    "Downtime failed after 1 attempts (exit 0)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Probe = "p"
+			w, _, _, notifier, _ := newTestWorker(tt.spec, RunnerFunc(func() Result { return tt.result }))
+
+			rec := w.Fire(1)
+			if rec.Status != tt.status {
+				t.Errorf("status %q, want %q", rec.Status, tt.status)
+			}
+			if sent := notifier.sent(); len(sent) != 1 || sent[0].msg != tt.msg {
+				t.Errorf("notifications %v, want message %q", sent, tt.msg)
+			}
+		})
+	}
+}
+
+func TestWorkerTimeoutNotifyIsOptional(t *testing.T) {
+	runner := RunnerFunc(func() Result { return Result{ExitCode: -1, TimedOut: true, Err: errors.New("timed out")} })
+	w, _, store, notifier, log := newTestWorker(Spec{Probe: "p", Timeout: time.Second}, runner)
+
+	if rec := w.Fire(1); rec.Status != StatusTimeout {
+		t.Fatalf("status %q, want timeout", rec.Status)
+	}
+	if len(notifier.sent()) != 0 {
+		t.Errorf("timeout notified without notify_timeout: %v", notifier.sent())
+	}
+	if len(store.records()) != 1 || !log.contains("timeout notification suppressed") {
+		t.Error("timeout not recorded and logged")
+	}
+}
+
+func TestWorkerNotifyOnly(t *testing.T) {
+	runner := RunnerFunc(func() Result {
+		t.Error("notify-only probe ran its script")
+		return Result{}
+	})
+	w, _, store, notifier, log := newTestWorker(Spec{Probe: "p", Duration: time.Minute, NotifyOnly: true}, runner)
+
+	if got := w.Run(); got != StatusSuccess {
+		t.Errorf("status %q, want success", got)
+	}
+	if len(store.records()) != 0 {
+		t.Error("notify-only probe recorded a run")
+	}
+	if len(notifier.sent()) != 1 {
+		t.Error("notify-only probe did not notify")
+	}
+	if !log.contains("notify-only mode") {
+		t.Error("notify-only mode was not logged")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWorkerNotifyOn(t *testing.T) {
+	tests := []struct {
+		policy string
+		codes  []int
+		want   int
+	}{
+		{"", []int{0, 1, 0}, 3},
+		{NotifyAlways, []int{0, 1, 0}, 3},
+		{NotifyFailure, []int{0, 1, 1}, 2},
+		{NotifySuccess, []int{0, 1, 0}, 2},
+		{NotifyChange, []int{0, 0, 1, 1, 0}, 2},
+		{NotifyChange, []int{1, 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			spec := Spec{Probe: "p", Duration: time.Minute, Iterations: len(tt.codes), NotifyOn: tt.policy}
+			w, _, _, notifier, log := newTestWorker(spec, exits(tt.codes...))
+			w.Run()
+
+			if got := len(notifier.sent()); got != tt.want {
+				t.Errorf("%d notifications for %v, want %d", got, tt.codes, tt.want)
+			}
+			if got := len(notifier.sent()); got < len(tt.codes) && !log.contains("notification suppressed") {
+				t.Error("suppressed notification was not logged")
+			}
+		})
+	}
+}
+
+func TestWorkerNotifyTemplates(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  Spec
+		title string
+		msg   string
+	}{
+		{
+			name:  "defaults",
+			title: "Hypnos-p",
+			msg:   "Downtime complete",
+		},
+		{
+			name: "builtin fields",
+			spec: Spec{
+				Group:         "work",
+				NotifyTitle:   "{{.Group}}/{{.Probe}} #{{.Iteration}}",
+				NotifyMessage: "{{.Status}} ({{.ExitCode}}, {{.Attempts}}): {{.LastLine}}",
+			},
+			title: "work/p #1",
+			msg:   "success (0, 1): exit 0",
+		},
+		{
+			name: "vars",
+			spec: Spec{
+				NotifyTitle: "backup {{.target}}",
+				Vars:        map[string]string{"target": "/srv"},
+			},
+			title: "backup /srv",
+			msg:   "Downtime complete",
+		},
+		{
+			name: "builtin fields win over vars",
+			spec: Spec{
+				NotifyTitle: "{{.Probe}}",
+				Vars:        map[string]string{"Probe": "shadow"},
+			},
+			title: "p",
+			msg:   "Downtime complete",
+		},
+		{
+			name: "broken templates fall back",
+			spec: Spec{
+				NotifyTitle:   "{{.missing}}",
+				NotifyMessage: "{{.missing}}",
+			},
+			title: "Hypnos-p",
+			msg:   "Downtime complete",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Probe = "p"
+			w, _, _, notifier, _ := newTestWorker(tt.spec, exits(0))
+			w.Fire(1)
+
+			want := []note{{tt.title, tt.msg}}
+			if got := notifier.sent(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWorkerDependencyFailures(t *testing.T) {
+	w, _, store, notifier, log := newTestWorker(Spec{Probe: "p"}, exits(0))
+	store.err = errors.New("disk full")
+	notifier.err = errors.New("no desktop")
+
+	if rec := w.Fire(1); rec.Status != StatusSuccess {
+		t.Errorf("status %q, want success", rec.Status)
+	}
+	for _, want := range []string{"recording history failed: disk full", "notify failed: no desktop"} {
+		if !log.contains(want) {
+			t.Errorf("log lacks %q", want)
+		}
+	}
+
+	bare := &Worker{Spec: Spec{Probe: "p"}, Runner: exits(0)}
+	if rec := bare.Fire(1); rec.Status != StatusSuccess {
+		t.Errorf("worker without store, notifier or log: status %q", rec.Status)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// waitOrFail fails the test when done does not close in time, instead of hanging the suite
+func waitOrFail(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestWorkerOverlapSkip(t *testing.T) {
+	release := make(chan struct{})
+	runner := RunnerFunc(func() Result {
+		<-release
+		return Result{}
+	})
+	spec := Spec{Probe: "p", Duration: time.Minute, Iterations: 2, Overlap: OverlapSkip}
+	w, _, store, _, log := newTestWorker(spec, runner)
+
+	// the first run blocks until the second firing has been skipped
+	store.onAppend = func(rec RunRecord) {
+		if rec.Status == StatusSkipped {
+			close(release)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+	waitOrFail(t, done, "skip policy")
+
+	var statuses []string
+	for _, rec := range store.records() {
+		statuses = append(statuses, rec.Status)
+	}
+	if want := []string{StatusSkipped, StatusSuccess}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("recorded %v, want %v", statuses, want)
+	}
+	if !log.contains("iteration 2 skipped") {
+		t.Error("skipped iteration was not logged")
+	}
+}
+
+func TestWorkerOverlapParallel(t *testing.T) {
+	const n = 3
+	var started sync.WaitGroup
+	started.Add(n)
+	all := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(all)
+	}()
+
+	// every run waits for the others, so this only finishes when all of them run at once
+	runner := RunnerFunc(func() Result {
+		started.Done()
+		<-all
+		return Result{}
+	})
+	w, _, store, _, _ := newTestWorker(Spec{Probe: "p", Duration: time.Minute, Iterations: n, Overlap: OverlapParallel}, runner)
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+	waitOrFail(t, done, "parallel runs")
+
+	if got := len(store.records()); got != n {
+		t.Errorf("%d runs recorded, want %d", got, n)
+	}
+}
+
+func TestWorkerOverlapQueue(t *testing.T) {
+	var active, peak atomic.Int32
+	runner := RunnerFunc(func() Result {
+		if now := active.Add(1); now > peak.Load() {
+			peak.Store(now)
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		return Result{}
+	})
+	w, _, store, _, _ := newTestWorker(Spec{Probe: "p", Duration: time.Minute, Iterations: 5}, runner)
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+	waitOrFail(t, done, "queued runs")
+
+	if peak.Load() != 1 {
+		t.Errorf("queued runs overlapped (%d at once)", peak.Load())
+	}
+	var order []int
+	for _, rec := range store.records() {
+		order = append(order, rec.Iteration)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(order, want) {
+		t.Errorf("ran iterations %v, want %v", order, want)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		backoff, max time.Duration
+		attempt      int
+		want         time.Duration
+	}{
+		{time.Second, 0, 1, time.Second},
+		{time.Second, 0, 2, 2 * time.Second},
+		{time.Second, 0, 4, 8 * time.Second},
+		{time.Second, 5 * time.Second, 4, 5 * time.Second},
+		{10 * time.Second, 5 * time.Second, 1, 5 * time.Second},
+		{0, 0, 3, 0},
+	}
+
+	for _, tt := range tests {
+		if got := RetryDelay(tt.backoff, tt.max, tt.attempt); got != tt.want {
+			t.Errorf("RetryDelay(%s, %s, %d) = %s, want %s", tt.backoff, tt.max, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOverlap(t *testing.T) {
+	for _, policy := range []string{"", OverlapSkip, OverlapQueue, OverlapParallel} {
+		if err := ValidateOverlap(policy); err != nil {
+			t.Errorf("ValidateOverlap(%q) = %v", policy, err)
+		}
+	}
+	if err := ValidateOverlap("drop"); err == nil {
+		t.Error("ValidateOverlap accepted an unknown policy")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestTriggerOnFakeClock(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset bool
+		// waiters pending once the trigger is handled: a reset leaves the old countdown behind
+		waiters int
+		want    time.Duration
+	}{
+		{"keep schedule", false, 1, time.Hour},
+		{"reset countdown", true, 2, 80 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(epoch)
+			fires := make(chan time.Time, 2)
+			runner := RunnerFunc(func() Result {
+				fires <- clock.Now()
+				return Result{}
+			})
+			control := make(chan Command)
+			w, _, store, _, log := newTestWorker(Spec{Probe: "p", Duration: time.Hour, Iterations: 1}, runner)
+			w.Clock, w.Control = clock, control
+
+			done := make(chan struct{})
+			go func() {
+				w.Run()
+				close(done)
+			}()
+
+			clock.BlockUntil(1)
+			clock.Advance(20 * time.Minute)
+			control <- Trigger{Reset: tt.reset}
+			select {
+			case at := <-fires:
+				if want := epoch.Add(20 * time.Minute); !at.Equal(want) {
+					t.Errorf("manual run at %s, want %s", at, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("manual trigger never fired")
+			}
+
+			clock.BlockUntil(tt.waiters)
+			clock.Advance(tt.want - 20*time.Minute - time.Minute)
+			select {
+			case at := <-fires:
+				t.Fatalf("scheduled run fired early at %s", at)
+			case <-time.After(10 * time.Millisecond):
+			}
+			clock.Advance(time.Minute)
+			waitOrFail(t, done, "worker to finish")
+
+			if at := <-fires; !at.Equal(epoch.Add(tt.want)) {
+				t.Errorf("scheduled run at %s, want %s", at, epoch.Add(tt.want))
+			}
+			recs := store.records()
+			if len(recs) != 2 || recs[0].Iteration != 0 || recs[1].Iteration != 1 {
+				t.Errorf("records %+v, want the manual run then iteration 1", recs)
+			}
+			if !log.contains("manual trigger") {
+				t.Error("manual trigger not logged")
+			}
+		})
+	}
+}
+
+func TestRescheduleOnFakeClock(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Duration
+		want time.Duration
+	}{
+		{"postpone", 90 * time.Minute, 90 * time.Minute},
+		{"advance", 30 * time.Minute, 30 * time.Minute},
+		{"past", 5 * time.Minute, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(epoch)
+			fires := make(chan time.Time, 1)
+			runner := RunnerFunc(func() Result {
+				fires <- clock.Now()
+				return Result{}
+			})
+			control := make(chan Command)
+			var deadlines []time.Time
+			w, _, _, _, _ := newTestWorker(Spec{Probe: "p", Duration: time.Hour, Iterations: 1}, runner)
+			w.Clock, w.Control = clock, control
+			w.OnDeadline = func(next time.Time) { deadlines = append(deadlines, next) }
+
+			done := make(chan struct{})
+			go func() {
+				w.Run()
+				close(done)
+			}()
+
+			clock.BlockUntil(1)
+			clock.Advance(10 * time.Minute)
+			control <- Reschedule{At: epoch.Add(tt.at)}
+
+			// a deadline already past fires at once; any other leaves the old countdown behind
+			if tt.want > 10*time.Minute {
+				clock.BlockUntil(2)
+				clock.Advance(tt.want - 10*time.Minute - time.Minute)
+				select {
+				case at := <-fires:
+					t.Fatalf("fired at %s, before the new deadline", at)
+				case <-time.After(10 * time.Millisecond):
+				}
+				clock.Advance(time.Minute)
+			}
+			waitOrFail(t, done, "worker to finish")
+
+			if at := <-fires; !at.Equal(epoch.Add(tt.want)) {
+				t.Errorf("fired at %s, want %s", at, epoch.Add(tt.want))
+			}
+			want := []time.Time{epoch.Add(time.Hour), epoch.Add(tt.at), {}}
+			if !slices.EqualFunc(deadlines, want, time.Time.Equal) {
+				t.Errorf("deadlines %v, want %v", deadlines, want)
+			}
+		})
+	}
+}
+
+func TestPauseResumeStopOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	fires := make(chan time.Time, 2)
+	runner := RunnerFunc(func() Result {
+		fires <- clock.Now()
+		return Result{}
+	})
+	control := make(chan Command)
+	w, _, _, _, log := newTestWorker(Spec{Probe: "p", Duration: time.Hour, Recurrent: true}, runner)
+	w.Clock, w.Control = clock, control
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+	status := func() State {
+		reply := make(chan State, 1)
+		control <- Status{Reply: reply}
+		return <-reply
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(45 * time.Minute)
+	control <- Pause{}
+	if st := status(); !st.Paused || st.Remaining != 15*time.Minute || !st.Next.IsZero() {
+		t.Fatalf("paused state %+v", st)
+	}
+
+	clock.Advance(3 * time.Hour)
+	select {
+	case at := <-fires:
+		t.Fatalf("fired at %s while paused", at)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	control <- Resume{}
+	clock.BlockUntil(1)
+	clock.Advance(15 * time.Minute)
+	select {
+	case at := <-fires:
+		if want := epoch.Add(4 * time.Hour); !at.Equal(want) {
+			t.Errorf("resumed firing at %s, want %s", at, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("resumed schedule never fired")
+	}
+
+	if st := status(); st.Fired != 1 || st.Paused || !st.Next.Equal(epoch.Add(5*time.Hour)) || st.LastStatus != StatusSuccess {
+		t.Errorf("state after firing %+v", st)
+	}
+
+	control <- Stop{}
+	waitOrFail(t, done, "worker to stop")
+	if !w.Stopped() || !log.contains("stopped (ran 1 times)") {
+		t.Error("stop not reported")
+	}
+}
+
+func TestWorkerCancelsReplacedWaits(t *testing.T) {
+	clock := NewHeapClock(1)
+	control := make(chan Command)
+	w, _, _, _, _ := newTestWorker(Spec{Probe: "p", Duration: time.Hour, Recurrent: true}, exits(0))
+	w.Clock, w.Control = clock, control
+
+	done := make(chan struct{})
+	go func() {
+		w.Run()
+		close(done)
+	}()
+
+	// every rearm replaces the wait, which must not pile up on the shared heap
+	for i := 1; i <= 5; i++ {
+		control <- Reschedule{At: clock.Now().Add(time.Duration(i) * time.Hour)}
+		control <- Pause{}
+		control <- Resume{}
+		control <- Trigger{Reset: true}
+	}
+	control <- Status{Reply: make(chan State, 1)}
+	if n := clock.Pending(); n != 1 {
+		t.Errorf("%d wakeups pending for one schedule, want 1", n)
+	}
+
+	control <- Stop{}
+	waitOrFail(t, done, "worker to stop")
+	if n := clock.Pending(); n != 0 {
+		t.Errorf("%d wakeups left after the worker stopped", n)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////